@@ -0,0 +1,59 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Codec converts Go values to and from the JSON representation of samples
+// used by the C layer. Its methods have the signatures of json.Marshal and
+// json.Unmarshal, so that a compatible JSON package can be plugged in.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// jsonCodec is the default Codec, based on encoding/json
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+var (
+	codecMutex sync.RWMutex
+	codec      Codec = jsonCodec{}
+)
+
+// SetCodec registers the Codec used by the functions converting samples to
+// and from Go values: Instance.Set, Output.WriteBatch, Samples.Get,
+// Input.TakeInto and SampleSnapshot.Get. Pass nil to restore encoding/json.
+func SetCodec(c Codec) {
+	codecMutex.Lock()
+	defer codecMutex.Unlock()
+	if c == nil {
+		c = jsonCodec{}
+	}
+	codec = c
+}
+
+// getCodec returns the registered Codec
+func getCodec() Codec {
+	codecMutex.RLock()
+	defer codecMutex.RUnlock()
+	return codec
+}
@@ -0,0 +1,695 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The XML configuration is parsed in Go for the information that
+// the C layer does not expose (e.g. the list of DataReaders and DataWriters)
+
+type xmlDDS struct {
+	Types                []xmlModule             `xml:"types"`
+	DomainLibraries      []xmlDomainLibrary      `xml:"domain_library"`
+	ParticipantLibraries []xmlParticipantLibrary `xml:"domain_participant_library"`
+}
+
+// xmlModule is a <types> or a <module> element
+type xmlModule struct {
+	Name    string      `xml:"name,attr"`
+	Structs []xmlStruct `xml:"struct"`
+	Enums   []xmlEnum   `xml:"enum"`
+	Modules []xmlModule `xml:"module"`
+}
+
+type xmlStruct struct {
+	Name     string      `xml:"name,attr"`
+	BaseType string      `xml:"baseType,attr"`
+	Members  []xmlMember `xml:"member"`
+}
+
+type xmlEnum struct {
+	Name        string          `xml:"name,attr"`
+	Enumerators []xmlEnumerator `xml:"enumerator"`
+}
+
+type xmlEnumerator struct {
+	Name  string `xml:"name,attr"`
+	Value *int   `xml:"value,attr"` // previous value + 1 when not set, starting from 0
+}
+
+type xmlMember struct {
+	Name              string `xml:"name,attr"`
+	Type              string `xml:"type,attr"`
+	NonBasicTypeName  string `xml:"nonBasicTypeName,attr"`
+	Key               bool   `xml:"key,attr"`
+	Optional          bool   `xml:"optional,attr"`
+	ArrayDimensions   string `xml:"arrayDimensions,attr"`
+	SequenceMaxLength string `xml:"sequenceMaxLength,attr"`
+	StringMaxLength   string `xml:"stringMaxLength,attr"`
+}
+
+type xmlDomainLibrary struct {
+	Name    string      `xml:"name,attr"`
+	Domains []xmlDomain `xml:"domain"`
+}
+
+type xmlDomain struct {
+	Name          string            `xml:"name,attr"`
+	DomainID      *int              `xml:"domain_id,attr"`
+	RegisterTypes []xmlRegisterType `xml:"register_type"`
+	Topics        []xmlTopic        `xml:"topic"`
+}
+
+type xmlRegisterType struct {
+	Name    string `xml:"name,attr"`
+	TypeRef string `xml:"type_ref,attr"`
+}
+
+type xmlTopic struct {
+	Name            string `xml:"name,attr"`
+	RegisterTypeRef string `xml:"register_type_ref,attr"`
+}
+
+type xmlParticipantLibrary struct {
+	Name         string           `xml:"name,attr"`
+	Participants []xmlParticipant `xml:"domain_participant"`
+}
+
+type xmlParticipant struct {
+	Name        string          `xml:"name,attr"`
+	DomainRef   string          `xml:"domain_ref,attr"`
+	DomainID    *int            `xml:"domain_id"`
+	Publishers  []xmlPublisher  `xml:"publisher"`
+	Subscribers []xmlSubscriber `xml:"subscriber"`
+}
+
+type xmlPublisher struct {
+	Name    string      `xml:"name,attr"`
+	Writers []xmlEntity `xml:"data_writer"`
+}
+
+type xmlSubscriber struct {
+	Name    string      `xml:"name,attr"`
+	Readers []xmlEntity `xml:"data_reader"`
+}
+
+type xmlEntity struct {
+	Name     string `xml:"name,attr"`
+	TopicRef string `xml:"topic_ref,attr"`
+}
+
+// loadDocuments reads the XML documents referenced by url, using the same
+// conventions as NewConnector: a str:// inline document or a list of files
+// separated by semicolons, optionally prefixed by file://
+func loadDocuments(url string) (documents [][]byte, err error) {
+	if strings.HasPrefix(url, "str://") {
+		document := strings.TrimPrefix(url, "str://")
+		document = strings.TrimSuffix(strings.TrimPrefix(document, "\""), "\"")
+		documents = append(documents, []byte(document))
+		return documents, nil
+	}
+
+	for _, fileName := range strings.Split(url, ";") {
+		fileName = strings.TrimPrefix(strings.TrimSpace(fileName), "file://")
+		if fileName == "" {
+			continue
+		}
+		document, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	return documents, nil
+}
+
+// loadConfig parses the XML documents referenced by url
+func loadConfig(url string) (configs []*xmlDDS, err error) {
+	documents, err := loadDocuments(url)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, document := range documents {
+		config := new(xmlDDS)
+		err = xml.Unmarshal(document, config)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// checkXML returns a descriptive error if document is not a well-formed
+// XML document with a <dds> root element
+func checkXML(document string) (err error) {
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	root := ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			err = errors.New("Invalid XML document: " + err.Error())
+			return err
+		}
+		if element, ok := token.(xml.StartElement); ok && root == "" {
+			root = element.Name.Local
+		}
+	}
+	if root != "dds" {
+		err = errors.New("Invalid XML document: the root element must be <dds>")
+		return err
+	}
+	return nil
+}
+
+// inlineURL returns the str:// URL of an XML document for the C layer,
+// which expects the document on a single line between double quotes
+func inlineURL(document string) string {
+	document = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(strings.TrimSpace(document))
+	return "str://\"" + document + "\""
+}
+
+// envPattern matches the ${NAME} references to environment variables
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv returns a str:// URL of the XML documents referenced by url in
+// which the ${NAME} references are replaced with the values of the environment
+// variables, empty if not set. Several documents are merged into one.
+func expandEnv(url string) (expandedURL string, err error) {
+	documents, err := loadDocuments(url)
+	if err != nil {
+		return "", err
+	}
+	for i, document := range documents {
+		documents[i] = envPattern.ReplaceAllFunc(document, func(reference []byte) []byte {
+			return []byte(os.Getenv(string(reference[2 : len(reference)-1])))
+		})
+	}
+	if len(documents) == 1 {
+		return inlineURL(string(documents[0])), nil
+	}
+
+	merged, err := mergeDocuments(documents)
+	if err != nil {
+		return "", err
+	}
+	cleanNodes(merged)
+	document, err := xml.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+	return inlineURL(string(document)), nil
+}
+
+// findParticipant returns the XML definition of the participant
+// named configName ("ParticipantLibrary::Participant")
+func findParticipant(configs []*xmlDDS, configName string) (participant *xmlParticipant, err error) {
+	names := strings.Split(configName, "::")
+	if len(names) != 2 {
+		err = errors.New("Invalid participant profile: " + configName)
+		return nil, err
+	}
+
+	for _, config := range configs {
+		for i := range config.ParticipantLibraries {
+			library := &config.ParticipantLibraries[i]
+			if library.Name != names[0] {
+				continue
+			}
+			for j := range library.Participants {
+				if library.Participants[j].Name == names[1] {
+					return &library.Participants[j], nil
+				}
+			}
+		}
+	}
+	err = errors.New("Participant profile not found: " + configName)
+	return nil, err
+}
+
+// findDomain returns the XML definition of the domain named domainRef ("DomainLibrary::Domain")
+func findDomain(configs []*xmlDDS, domainRef string) (domain *xmlDomain, err error) {
+	names := strings.Split(domainRef, "::")
+	if len(names) == 2 {
+		for _, config := range configs {
+			for i := range config.DomainLibraries {
+				library := &config.DomainLibraries[i]
+				if library.Name != names[0] {
+					continue
+				}
+				for j := range library.Domains {
+					if library.Domains[j].Name == names[1] {
+						return &library.Domains[j], nil
+					}
+				}
+			}
+		}
+	}
+	err = errors.New("Domain not found: " + domainRef)
+	return nil, err
+}
+
+// findInModules returns the first definition found by find in the modules of
+// the configuration. find is given each module with the prefix qualifying the
+// names of its definitions (e.g. "Module::").
+func findInModules[T any](configs []*xmlDDS, find func(module *xmlModule, prefix string) *T) *T {
+	var search func(module *xmlModule, prefix string) *T
+	search = func(module *xmlModule, prefix string) *T {
+		found := find(module, prefix)
+		if found != nil {
+			return found
+		}
+		for i := range module.Modules {
+			found = search(&module.Modules[i], prefix+module.Modules[i].Name+"::")
+			if found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+
+	for _, config := range configs {
+		for i := range config.Types {
+			found := search(&config.Types[i], "")
+			if found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// findStruct returns the XML definition of the struct named typeName,
+// which is qualified with its modules (e.g. "Module::Type")
+func findStruct(configs []*xmlDDS, typeName string) (typ *xmlStruct, err error) {
+	typeName = strings.TrimPrefix(typeName, "::")
+	typ = findInModules(configs, func(module *xmlModule, prefix string) *xmlStruct {
+		for i := range module.Structs {
+			if prefix+module.Structs[i].Name == typeName {
+				return &module.Structs[i]
+			}
+		}
+		return nil
+	})
+	if typ == nil {
+		err = errors.New("Type not found: " + typeName)
+		return nil, err
+	}
+	return typ, nil
+}
+
+// findEnum returns the XML definition of the enum named typeName,
+// which is qualified with its modules (e.g. "Module::Enum")
+func findEnum(configs []*xmlDDS, typeName string) (enum *xmlEnum, err error) {
+	typeName = strings.TrimPrefix(typeName, "::")
+	enum = findInModules(configs, func(module *xmlModule, prefix string) *xmlEnum {
+		for i := range module.Enums {
+			if prefix+module.Enums[i].Name == typeName {
+				return &module.Enums[i]
+			}
+		}
+		return nil
+	})
+	if enum == nil {
+		err = errors.New("Enum not found: " + typeName)
+		return nil, err
+	}
+	return enum, nil
+}
+
+// values returns the value of each enumerator by name
+func (enum *xmlEnum) values() map[string]int {
+	values := make(map[string]int, len(enum.Enumerators))
+	value := 0
+	for _, enumerator := range enum.Enumerators {
+		if enumerator.Value != nil {
+			value = *enumerator.Value
+		}
+		values[enumerator.Name] = value
+		value++
+	}
+	return values
+}
+
+// findMember returns the XML definition of a member of a struct.
+// Nested members are separated by dots (e.g. "pos.x") and the index
+// of an element of an array or a sequence (e.g. "[1]") is ignored.
+func findMember(configs []*xmlDDS, typ *xmlStruct, fieldName string) (member *xmlMember, err error) {
+	names := strings.Split(fieldName, ".")
+	for i, name := range names {
+		if bracket := strings.IndexByte(name, '['); bracket >= 0 {
+			name = name[:bracket]
+		}
+		members, err := allMembers(configs, typ)
+		if err != nil {
+			return nil, err
+		}
+		member = nil
+		for j := range members {
+			if members[j].Name == name {
+				member = &members[j]
+			}
+		}
+		if member == nil {
+			err = errors.New("Invalid field name: " + fieldName)
+			return nil, err
+		}
+		if i < len(names)-1 {
+			typ, err = findStruct(configs, member.NonBasicTypeName)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return member, nil
+}
+
+// allMembers returns the members of a struct, including those of its base types
+func allMembers(configs []*xmlDDS, typ *xmlStruct) (members []xmlMember, err error) {
+	if typ.BaseType != "" {
+		baseType, err := findStruct(configs, typ.BaseType)
+		if err != nil {
+			return nil, err
+		}
+		members, err = allMembers(configs, baseType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return append(members, typ.Members...), nil
+}
+
+// config returns the XML configuration of the connector, parsing it the first time
+func (connector *Connector) config() (configs []*xmlDDS, err error) {
+	if connector == nil {
+		err = errors.New("Connector is null")
+		return nil, err
+	}
+
+	// Inputs and outputs used by different goroutines may parse it concurrently
+	connector.configMutex.Lock()
+	defer connector.configMutex.Unlock()
+	if connector.configs == nil {
+		connector.configs, err = loadConfig(connector.url)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return connector.configs, nil
+}
+
+// participant returns the XML definition of the participant of the connector
+func (connector *Connector) participant() (participant *xmlParticipant, err error) {
+	configs, err := connector.config()
+	if err != nil {
+		return nil, err
+	}
+	return findParticipant(configs, connector.configName)
+}
+
+// entityType returns the XML definition of the type of the topic of an
+// input ("Subscriber::DataReader") or an output ("Publisher::DataWriter")
+func (connector *Connector) entityType(entityName string) (typ *xmlStruct, err error) {
+	participant, err := connector.participant()
+	if err != nil {
+		return nil, err
+	}
+
+	topicRef := ""
+	for _, publisher := range participant.Publishers {
+		for _, writer := range publisher.Writers {
+			if publisher.Name+"::"+writer.Name == entityName {
+				topicRef = writer.TopicRef
+			}
+		}
+	}
+	for _, subscriber := range participant.Subscribers {
+		for _, reader := range subscriber.Readers {
+			if subscriber.Name+"::"+reader.Name == entityName {
+				topicRef = reader.TopicRef
+			}
+		}
+	}
+	if topicRef == "" {
+		err = errors.New("Topic not found for " + entityName)
+		return nil, err
+	}
+
+	configs, err := connector.config()
+	if err != nil {
+		return nil, err
+	}
+	domain, err := findDomain(configs, participant.DomainRef)
+	if err != nil {
+		return nil, err
+	}
+
+	registerTypeRef := ""
+	for _, topic := range domain.Topics {
+		if topic.Name == topicRef {
+			registerTypeRef = topic.RegisterTypeRef
+		}
+	}
+	for _, registerType := range domain.RegisterTypes {
+		if registerType.Name == registerTypeRef {
+			return findStruct(configs, registerType.TypeRef)
+		}
+	}
+	err = errors.New("Type not found for topic " + topicRef)
+	return nil, err
+}
+
+// memberEnum returns the XML definition of the enum type of a member
+// of the type of an input or an output
+func (connector *Connector) memberEnum(entityName string, fieldName string) (enum *xmlEnum, err error) {
+	configs, err := connector.config()
+	if err != nil {
+		return nil, err
+	}
+	typ, err := connector.entityType(entityName)
+	if err != nil {
+		return nil, err
+	}
+	member, err := findMember(configs, typ, fieldName)
+	if err != nil {
+		return nil, err
+	}
+	if member.Type != "nonBasic" {
+		err = errors.New("Not an enum: " + fieldName)
+		return nil, err
+	}
+	return findEnum(configs, member.NonBasicTypeName)
+}
+
+// bound returns the maximum number of elements of an array or a sequence
+// member, or -1 if the sequence is unbounded
+func (member *xmlMember) bound(fieldName string) (bound int, err error) {
+	switch {
+	case member.ArrayDimensions != "":
+		// Multi-dimensional arrays are set as a flat list of elements
+		bound = 1
+		for _, dimension := range strings.Split(member.ArrayDimensions, ",") {
+			size, err := strconv.Atoi(strings.TrimSpace(dimension))
+			if err != nil {
+				err = errors.New("Invalid array dimensions of " + fieldName)
+				return 0, err
+			}
+			bound *= size
+		}
+		return bound, nil
+	case member.SequenceMaxLength != "":
+		bound, err = strconv.Atoi(member.SequenceMaxLength)
+		if err != nil {
+			err = errors.New("Invalid sequence length of " + fieldName)
+			return 0, err
+		}
+		if bound < 0 {
+			return -1, nil
+		}
+		return bound, nil
+	}
+	err = errors.New("Not an array or a sequence: " + fieldName)
+	return 0, err
+}
+
+// checkString returns an error when a value is longer than the member
+// declared with stringMaxLength: in bytes for a string, and in characters
+// for a wstring. Unbounded members and those of other types are not checked.
+func (member *xmlMember) checkString(fieldName string, value string) error {
+	bound, err := strconv.Atoi(member.StringMaxLength)
+	if err != nil || bound < 0 {
+		return nil
+	}
+
+	length, unit := len(value), " bytes"
+	if member.Type == "wstring" {
+		length, unit = utf8.RuneCountInString(value), " characters"
+	}
+	if length > bound {
+		return errors.New("String too long for " + fieldName + ": " + strconv.Itoa(length) + unit + ", the maximum length is " + strconv.Itoa(bound))
+	}
+	return nil
+}
+
+// memberLookup is the result of a lookup cached by Output.member
+type memberLookup struct {
+	member *xmlMember
+	err    error
+}
+
+// member returns the XML definition of a member of the type of the output.
+// The lookups are cached by member, ignoring the indexes of the elements, so
+// that the XML configuration is only walked the first time; a failed lookup
+// is logged then.
+func (output *Output) member(fieldName string) (member *xmlMember, err error) {
+	path := memberPath(fieldName)
+	if output.members != nil {
+		if cached, ok := output.members.Load(path); ok {
+			lookup := cached.(memberLookup)
+			return lookup.member, lookup.err
+		}
+	}
+
+	configs, err := output.connector.config()
+	if err == nil {
+		var typ *xmlStruct
+		typ, err = output.connector.entityType(output.name)
+		if err == nil {
+			member, err = findMember(configs, typ, path)
+		}
+	}
+	if err != nil {
+		output.connector.log(LogError, "Cannot find "+path+" of "+output.name+" in the XML configuration: "+err.Error())
+	}
+	if output.members != nil {
+		output.members.Store(path, memberLookup{member: member, err: err})
+	}
+	return member, err
+}
+
+// memberPath returns a member name without the indexes of the elements,
+// e.g. "pos.x" for "pos[2].x"
+func memberPath(fieldName string) string {
+	if strings.IndexByte(fieldName, '[') < 0 {
+		return fieldName
+	}
+	var path strings.Builder
+	for len(fieldName) > 0 {
+		start := strings.IndexByte(fieldName, '[')
+		if start < 0 {
+			path.WriteString(fieldName)
+			break
+		}
+		path.WriteString(fieldName[:start])
+		end := strings.IndexByte(fieldName[start:], ']')
+		if end < 0 {
+			break
+		}
+		fieldName = fieldName[start+end+1:]
+	}
+	return path.String()
+}
+
+// checkString checks a value for a string member of the type of the output
+// (see xmlMember.checkString). Members that are not found in the XML
+// configuration are not checked, and are reported by the C layer when set.
+func (output *Output) checkString(fieldName string, value string) error {
+	member, err := output.member(fieldName)
+	if err != nil {
+		return nil
+	}
+	return member.checkString(fieldName, value)
+}
+
+// ListOutputs returns the names ("Publisher::DataWriter") of all the outputs
+// defined for the participant in the XML configuration
+func (connector *Connector) ListOutputs() (outputNames []string, err error) {
+	participant, err := connector.participant()
+	if err != nil {
+		return nil, err
+	}
+
+	outputNames = []string{}
+	for _, publisher := range participant.Publishers {
+		for _, writer := range publisher.Writers {
+			outputNames = append(outputNames, publisher.Name+"::"+writer.Name)
+		}
+	}
+	return outputNames, nil
+}
+
+// ListInputs returns the names ("Subscriber::DataReader") of all the inputs
+// defined for the participant in the XML configuration
+func (connector *Connector) ListInputs() (inputNames []string, err error) {
+	participant, err := connector.participant()
+	if err != nil {
+		return nil, err
+	}
+
+	inputNames = []string{}
+	for _, subscriber := range participant.Subscribers {
+		for _, reader := range subscriber.Readers {
+			inputNames = append(inputNames, subscriber.Name+"::"+reader.Name)
+		}
+	}
+	return inputNames, nil
+}
+
+// GetDomainID returns the DDS domain joined by the participant of the connector,
+// as resolved from the XML configuration: the domain_id of the participant if set,
+// otherwise the domain_id of its domain, otherwise the default domain 0.
+func (connector *Connector) GetDomainID() (domainID int, err error) {
+	participant, err := connector.participant()
+	if err != nil {
+		return 0, err
+	}
+	if participant.DomainID != nil {
+		return *participant.DomainID, nil
+	}
+	if participant.DomainRef == "" {
+		return 0, nil
+	}
+
+	configs, err := connector.config()
+	if err != nil {
+		return 0, err
+	}
+	domain, err := findDomain(configs, participant.DomainRef)
+	if err != nil {
+		return 0, err
+	}
+	if domain.DomainID != nil {
+		return *domain.DomainID, nil
+	}
+	return 0, nil
+}
+
+// ParticipantName returns the participant profile ("ParticipantLibrary::Participant")
+// the connector was created with
+func (connector *Connector) ParticipantName() string {
+	if connector == nil {
+		return ""
+	}
+	return connector.configName
+}
@@ -0,0 +1,87 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// recordReception stores the time of a take that returned a valid sample,
+// for WatchDeadline
+func (input *Input) recordReception() {
+	if input.lastTaken == nil {
+		return
+	}
+	first := input.Samples.firstIndex()
+	for i := first; i < first+input.Samples.GetLength(); i++ {
+		if input.Infos.IsValid(i) {
+			input.lastTaken.Store(time.Now().UnixNano())
+			return
+		}
+	}
+}
+
+// WatchDeadline is a function to be notified when an input stops receiving
+// data. onMiss is called from a goroutine whenever a period elapses without
+// a valid sample, and then again every period until one arrives.
+// The C layer does not expose the reception timestamps, so a sample is
+// considered received when a take (Take and the functions based on it, such
+// as Stream) returns it. Read does not count, because it returns the same
+// samples again. Unlike the deadline QoS, it works without any XML setting.
+// The watch stops when the context is done or the connector is deleted,
+// which onMiss must not do itself.
+func (input *Input) WatchDeadline(ctx context.Context, period time.Duration, onMiss func()) error {
+	err := input.check()
+	if err != nil {
+		return err
+	}
+	if period <= 0 {
+		return errors.New("Invalid period")
+	}
+	if onMiss == nil {
+		return errors.New("Callback is null")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	connector := input.connector
+	lastTaken := input.lastTaken
+	connector.streams.Add(1)
+	go func() {
+		defer connector.streams.Done()
+
+		timer := time.NewTimer(period)
+		defer timer.Stop()
+		reference := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-connector.done:
+				return
+			case <-timer.C:
+			}
+
+			if last := time.Unix(0, lastTaken.Load()); last.After(reference) {
+				reference = last
+			}
+			if now := time.Now(); now.Sub(reference) >= period {
+				onMiss()
+				reference = now
+			}
+			timer.Reset(time.Until(reference.Add(period)))
+		}
+	}()
+	return nil
+}
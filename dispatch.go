@@ -0,0 +1,100 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"context"
+	"sort"
+)
+
+// Dispatch is a function to receive DDS samples with handlers. handlers maps
+// the name of an input ("Subscriber::DataReader") to the SampleHandler that
+// receives its samples. Dispatch waits for data, takes the DDS samples of each
+// input with pending samples and calls its handler, in the order of the input
+// names, from the calling goroutine. It loops until the context is done, and
+// then returns the error of the context, or until the connector is deleted.
+//
+// Wait returns as soon as any input has data, so the inputs without a handler
+// should be consumed elsewhere; otherwise Dispatch keeps waking up for them.
+// Delete and Reconnect wait for Dispatch to return, so the handlers must not
+// call them: cancel the context instead.
+func (connector *Connector) Dispatch(ctx context.Context, handlers map[string]SampleHandler) (err error) {
+	err = connector.check()
+	if err != nil {
+		return err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	inputs := make([]*Input, len(names))
+	for i, name := range names {
+		inputs[i], err = connector.findInput(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	connector.streams.Add(1)
+	defer connector.streams.Done()
+
+	dispatchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-connector.done:
+			cancel()
+		case <-dispatchCtx.Done():
+		}
+	}()
+
+	for {
+		err = connector.WaitWithContext(dispatchCtx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if dispatchCtx.Err() != nil {
+			// The connector was deleted
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for i, input := range inputs {
+			err = input.Take()
+			if err == ErrNoData {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			handlers[names[i]](input.Samples, input.Infos)
+		}
+	}
+}
+
+// findInput returns the input created by GetInput for inputName,
+// creating it if needed
+func (connector *Connector) findInput(inputName string) (input *Input, err error) {
+	for i := range connector.Inputs {
+		if connector.Inputs[i].name == inputName {
+			// Samples.input is the *Input returned by GetInput
+			return connector.Inputs[i].Samples.input, nil
+		}
+	}
+	return connector.GetInput(inputName)
+}
@@ -0,0 +1,75 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"runtime"
+)
+
+// InstanceEncoder writes Go values in an output, as Instance.Set and
+// Output.Write do, reusing its buffers across calls instead of allocating
+// a new JSON document and a new C string for each sample. It is meant for
+// high-rate writers. Values are marshalled with encoding/json, regardless
+// of SetCodec. An encoder must not be used by several goroutines at once.
+type InstanceEncoder struct {
+	output  *Output
+	buffer  bytes.Buffer
+	encoder *json.Encoder
+	native  jsonBuffer
+}
+
+// NewEncoder is a function to create an InstanceEncoder writing in the output.
+// Close releases the buffer it allocates in C.
+func (output *Output) NewEncoder() (encoder *InstanceEncoder, err error) {
+	err = output.check()
+	if err != nil {
+		return nil, err
+	}
+
+	encoder = &InstanceEncoder{output: output}
+	encoder.encoder = json.NewEncoder(&encoder.buffer)
+	runtime.SetFinalizer(encoder, (*InstanceEncoder).Close)
+	return encoder, nil
+}
+
+// Encode is a function to set all the members of the instance from v
+// and write it
+func (encoder *InstanceEncoder) Encode(v interface{}) (err error) {
+	if encoder == nil || encoder.encoder == nil {
+		err = errors.New("Encoder is null")
+		return err
+	}
+
+	encoder.buffer.Reset()
+	err = encoder.encoder.Encode(v)
+	if err != nil {
+		return err
+	}
+	err = encoder.output.Instance.setJSONBuffer(&encoder.native, bytes.TrimSpace(encoder.buffer.Bytes()))
+	if err != nil {
+		return err
+	}
+	return encoder.output.Write()
+}
+
+// Close is a function to release the buffers of the encoder
+func (encoder *InstanceEncoder) Close() error {
+	if encoder == nil {
+		return errors.New("Encoder is null")
+	}
+	runtime.SetFinalizer(encoder, nil)
+	encoder.native.free()
+	encoder.encoder = nil
+	return nil
+}
@@ -0,0 +1,77 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// GetEnumName is a function to retrieve the label of an enum member from the
+// samples. The numeric getters (e.g. GetInt32) return the value of the enum.
+// The label is found from the definition of the enum in the XML configuration.
+func (samples *Samples) GetEnumName(index int, fieldName string) (label string, err error) {
+	err = samples.check()
+	if err != nil {
+		return "", err
+	}
+
+	member, err := samples.getJSONMember(index, fieldName)
+	if err != nil {
+		return "", err
+	}
+	// The JSON of a sample may already hold the label
+	if len(member) > 0 && member[0] == '"' {
+		err = json.Unmarshal(member, &label)
+		if err != nil {
+			return "", err
+		}
+		return label, nil
+	}
+	value, err := strconv.Atoi(string(member))
+	if err != nil {
+		return "", err
+	}
+
+	enum, err := samples.input.connector.memberEnum(samples.input.name, fieldName)
+	if err != nil {
+		return "", err
+	}
+	for name, enumValue := range enum.values() {
+		if enumValue == value {
+			return name, nil
+		}
+	}
+	err = errors.New("Invalid value " + strconv.Itoa(value) + " for enum " + enum.Name)
+	return "", err
+}
+
+// SetEnumName is a function to set an enum member of the samples from its label.
+// The value of the label is found from the definition of the enum in the XML
+// configuration. The numeric setters (e.g. SetInt32) set the value directly.
+func (instance *Instance) SetEnumName(fieldName string, label string) (err error) {
+	err = instance.check()
+	if err != nil {
+		return err
+	}
+
+	enum, err := instance.output.connector.memberEnum(instance.output.name, fieldName)
+	if err != nil {
+		return err
+	}
+	value, ok := enum.values()[label]
+	if !ok {
+		err = errors.New("Invalid label " + label + " for enum " + enum.Name)
+		return err
+	}
+	return instance.SetInt(fieldName, value)
+}
@@ -0,0 +1,64 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rticommunity/rticonnextdds-connector-go"
+	"github.com/rticommunity/rticonnextdds-connector-go/metrics"
+	"log"
+	"net/http"
+	"path"
+	"runtime"
+	"time"
+)
+
+func main() {
+	// Find the file path to the XML configuration
+	_, filename, _, ok := runtime.Caller(0)
+	if !ok {
+		log.Panic("runtime.Caller error")
+	}
+	filepath := path.Join(path.Dir(filename), "../ShapeExample.xml")
+
+	// Create a connector counting the samples written, taken and read
+	connector, err := rti.NewConnectorWithOptions("MyParticipantLibrary::Zero", filepath, rti.ConnectorOptions{EnableStats: true})
+	if err != nil {
+		log.Panic(err)
+	}
+	// Delete the connector when this main function returns
+	defer connector.Delete()
+
+	// Get an output from the connector
+	output, err := connector.GetOutput("MyPublisher::MySquareWriter")
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// Register the metrics of the connector and serve them on /metrics
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics.NewCollector(connector))
+	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	go func() {
+		log.Fatal(http.ListenAndServe(":2112", nil))
+	}()
+
+	// Write samples and check the metrics with: curl localhost:2112/metrics
+	for i := 0; ; i++ {
+		output.Instance.SetString("color", "BLUE")
+		output.Instance.SetInt("x", i%200)
+		output.Instance.SetInt("y", i%200)
+		output.Instance.SetInt("shapesize", 30)
+		output.Write()
+		time.Sleep(time.Millisecond * 500)
+	}
+}
@@ -0,0 +1,116 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"bytes"
+	"strings"
+)
+
+// FieldKind is the kind of a member of a sample, as returned by Samples.GetFieldType
+type FieldKind int
+
+// The kinds of members. The kind is inferred from the JSON representation of
+// the sample, and refined with the XML type of the input when it is found in
+// the configuration: otherwise enums are reported as FieldInt and arrays as
+// FieldSequence.
+const (
+	FieldUnknown FieldKind = iota
+	FieldInt
+	FieldFloat
+	FieldString
+	FieldBool
+	FieldStruct
+	FieldSequence
+	FieldArray
+	FieldEnum
+)
+
+var fieldKindNames = [...]string{"Unknown", "Int", "Float", "String", "Bool", "Struct", "Sequence", "Array", "Enum"}
+
+// String returns the name of the kind
+func (kind FieldKind) String() string {
+	if kind < 0 || int(kind) >= len(fieldKindNames) {
+		return fieldKindNames[FieldUnknown]
+	}
+	return fieldKindNames[kind]
+}
+
+// GetFieldType is a function to retrieve the kind of a member from the samples
+func (samples *Samples) GetFieldType(index int, fieldName string) (kind FieldKind, err error) {
+	member, err := samples.getJSONMember(index, fieldName)
+	if err != nil {
+		return FieldUnknown, err
+	}
+	return samples.xmlKind(fieldName, jsonKind(member)), nil
+}
+
+// xmlKind refines the kind inferred from the JSON of a member with its XML
+// definition: arrays and sequences have the same JSON representation, and so
+// do enums and integers. The kind is returned unchanged when the type of the
+// input or the member is not found in the XML configuration.
+func (samples *Samples) xmlKind(fieldName string, kind FieldKind) FieldKind {
+	if kind != FieldSequence && kind != FieldInt && kind != FieldString {
+		return kind
+	}
+	configs, err := samples.input.connector.config()
+	if err != nil {
+		return kind
+	}
+	typ, err := samples.input.connector.entityType(samples.input.name)
+	if err != nil {
+		return kind
+	}
+	// findMember ignores the indexes, so the definition of an element is
+	// the one of its array or sequence
+	member, err := findMember(configs, typ, fieldName)
+	if err != nil {
+		return kind
+	}
+
+	if kind == FieldSequence {
+		if member.ArrayDimensions != "" && !strings.HasSuffix(fieldName, "]") {
+			return FieldArray
+		}
+		return kind
+	}
+	if member.Type == "nonBasic" {
+		if _, err := findEnum(configs, member.NonBasicTypeName); err == nil {
+			return FieldEnum
+		}
+	}
+	return kind
+}
+
+// jsonKind infers the kind of a member from its JSON representation
+func jsonKind(member []byte) FieldKind {
+	member = bytes.TrimSpace(member)
+	if len(member) == 0 {
+		return FieldUnknown
+	}
+
+	switch member[0] {
+	case '{':
+		return FieldStruct
+	case '[':
+		return FieldSequence
+	case '"':
+		return FieldString
+	case 't', 'f':
+		return FieldBool
+	case 'n':
+		return FieldUnknown
+	}
+	if bytes.ContainsAny(member, ".eE") {
+		return FieldFloat
+	}
+	return FieldInt
+}
@@ -0,0 +1,436 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filters are evaluated in Go on the JSON of each sample because the C layer
+// does not expose content filters or query conditions. The expressions use a
+// subset of the DDS SQL filter syntax:
+//
+//	expression := condition { (AND | OR) condition }
+//	condition  := NOT condition | "(" expression ")" | operand op operand
+//	op         := "=" | "<>" | "!=" | ">" | ">=" | "<" | "<="
+//	operand    := member | number | 'string' | TRUE | FALSE | %n
+//
+// Members are named as in the getters, with nested members separated by dots.
+// %n is replaced by params[n], which is a number, a 'string', TRUE or FALSE.
+// AND has precedence over OR.
+
+type filterNode interface {
+	eval(sample map[string]interface{}) bool
+}
+
+type filterAnd struct {
+	left, right filterNode
+}
+
+type filterOr struct {
+	left, right filterNode
+}
+
+type filterNot struct {
+	node filterNode
+}
+
+type filterComparison struct {
+	op          string
+	left, right filterOperand
+}
+
+type filterOperand struct {
+	member string      // name of the member, empty for a constant
+	value  interface{} // json.Number, string or bool constant
+}
+
+func (node filterAnd) eval(sample map[string]interface{}) bool {
+	return node.left.eval(sample) && node.right.eval(sample)
+}
+
+func (node filterOr) eval(sample map[string]interface{}) bool {
+	return node.left.eval(sample) || node.right.eval(sample)
+}
+
+func (node filterNot) eval(sample map[string]interface{}) bool {
+	return !node.node.eval(sample)
+}
+
+func (node filterComparison) eval(sample map[string]interface{}) bool {
+	result, ok := compareValues(node.left.resolve(sample), node.right.resolve(sample))
+	if !ok {
+		return false
+	}
+
+	switch node.op {
+	case "=":
+		return result == 0
+	case "<>", "!=":
+		return result != 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	}
+	return false
+}
+
+// resolve returns the value of the operand for a sample, nil if the member does not exist
+func (operand filterOperand) resolve(sample map[string]interface{}) interface{} {
+	if operand.member == "" {
+		return operand.value
+	}
+
+	var value interface{} = sample
+	for _, name := range strings.Split(operand.member, ".") {
+		members, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = members[name]
+	}
+	return value
+}
+
+// compareValues compares two values of the same type. Booleans can only be
+// compared for equality. ok is false when the values cannot be compared.
+func compareValues(left, right interface{}) (result int, ok bool) {
+	switch left := left.(type) {
+	case json.Number:
+		right, ok := right.(json.Number)
+		if !ok {
+			return 0, false
+		}
+		return compareNumbers(left, right)
+	case string:
+		right, ok := right.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(left, right), true
+	case bool:
+		right, ok := right.(bool)
+		if !ok {
+			return 0, false
+		}
+		if left == right {
+			return 0, true
+		}
+		return 1, true
+	}
+	return 0, false
+}
+
+func compareNumbers(left, right json.Number) (result int, ok bool) {
+	// Compare as integers first so that 64-bit integers are not rounded
+	leftInt, leftErr := left.Int64()
+	rightInt, rightErr := right.Int64()
+	if leftErr == nil && rightErr == nil {
+		switch {
+		case leftInt < rightInt:
+			return -1, true
+		case leftInt > rightInt:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	leftFloat, leftErr := left.Float64()
+	rightFloat, rightErr := right.Float64()
+	if leftErr != nil || rightErr != nil {
+		return 0, false
+	}
+	switch {
+	case leftFloat < rightFloat:
+		return -1, true
+	case leftFloat > rightFloat:
+		return 1, true
+	}
+	return 0, true
+}
+
+// filterParser is a recursive descent parser of filter expressions
+type filterParser struct {
+	tokens []string
+	pos    int
+	params []string
+}
+
+// parseFilter parses a filter expression, replacing %n with params[n]
+func parseFilter(expression string, params []string) (filter filterNode, err error) {
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &filterParser{tokens: tokens, params: params}
+	filter, err = parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos != len(parser.tokens) {
+		err = errors.New("Invalid filter expression: unexpected " + parser.tokens[parser.pos])
+		return nil, err
+	}
+	return filter, nil
+}
+
+func tokenizeFilter(expression string) (tokens []string, err error) {
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '=':
+			tokens = append(tokens, string(r))
+			i++
+		case r == '<' || r == '>' || r == '!':
+			j := i + 1
+			if j < len(runes) && (runes[j] == '=' || (r == '<' && runes[j] == '>')) {
+				j++
+			}
+			if string(runes[i:j]) == "!" {
+				err = errors.New("Invalid filter expression: unexpected !")
+				return nil, err
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case r == '\'':
+			// Quotes inside strings are escaped by doubling them
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == '\'' {
+					if j+1 < len(runes) && runes[j+1] == '\'' {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(runes) {
+				err = errors.New("Invalid filter expression: unterminated string")
+				return nil, err
+			}
+			tokens = append(tokens, string(runes[i:j+1]))
+			i = j + 1
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("()=<>!'", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func (parser *filterParser) peek() string {
+	if parser.pos < len(parser.tokens) {
+		return parser.tokens[parser.pos]
+	}
+	return ""
+}
+
+func (parser *filterParser) next() string {
+	token := parser.peek()
+	parser.pos++
+	return token
+}
+
+func (parser *filterParser) parseOr() (node filterNode, err error) {
+	node, err = parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(parser.peek(), "OR") {
+		parser.next()
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		node = filterOr{node, right}
+	}
+	return node, nil
+}
+
+func (parser *filterParser) parseAnd() (node filterNode, err error) {
+	node, err = parser.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(parser.peek(), "AND") {
+		parser.next()
+		right, err := parser.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		node = filterAnd{node, right}
+	}
+	return node, nil
+}
+
+func (parser *filterParser) parseCondition() (node filterNode, err error) {
+	if strings.EqualFold(parser.peek(), "NOT") {
+		parser.next()
+		node, err = parser.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{node}, nil
+	}
+
+	if parser.peek() == "(" {
+		parser.next()
+		node, err = parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if parser.next() != ")" {
+			err = errors.New("Invalid filter expression: missing )")
+			return nil, err
+		}
+		return node, nil
+	}
+
+	left, err := parser.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op := parser.next()
+	switch op {
+	case "=", "<>", "!=", ">", ">=", "<", "<=":
+	default:
+		err = errors.New("Invalid filter expression: expected a comparison operator instead of " + op)
+		return nil, err
+	}
+	right, err := parser.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return filterComparison{op, left, right}, nil
+}
+
+func (parser *filterParser) parseOperand() (operand filterOperand, err error) {
+	token := parser.next()
+	if token == "" {
+		err = errors.New("Invalid filter expression: unexpected end")
+		return operand, err
+	}
+
+	if strings.HasPrefix(token, "%") {
+		n, err := strconv.Atoi(token[1:])
+		if err != nil || n < 0 || n >= len(parser.params) {
+			err = errors.New("Invalid filter expression: no parameter for " + token)
+			return operand, err
+		}
+		operand.value = parseFilterConstant(parser.params[n])
+		return operand, nil
+	}
+
+	if value := parseFilterConstant(token); value != nil {
+		operand.value = value
+		return operand, nil
+	}
+	if token == "(" || token == ")" || strings.ContainsAny(token[:1], "=<>!") {
+		err = errors.New("Invalid filter expression: unexpected " + token)
+		return operand, err
+	}
+	operand.member = token
+	return operand, nil
+}
+
+// parseFilterConstant returns the value of a constant, nil if token is not a constant
+func parseFilterConstant(token string) interface{} {
+	token = strings.TrimSpace(token)
+	switch {
+	case len(token) >= 2 && strings.HasPrefix(token, "'") && strings.HasSuffix(token, "'"):
+		return strings.Replace(token[1:len(token)-1], "''", "'", -1)
+	case strings.EqualFold(token, "TRUE"):
+		return true
+	case strings.EqualFold(token, "FALSE"):
+		return false
+	}
+	if _, err := strconv.ParseFloat(token, 64); err == nil {
+		return json.Number(token)
+	}
+	return nil
+}
+
+// applyFilter only exposes the valid samples matching filter
+func (input *Input) applyFilter(filter filterNode) (err error) {
+	indexes := []int{}
+	first := input.Samples.firstIndex()
+	for i := 0; i < input.Samples.GetLength(); i++ {
+		if !input.Infos.IsValid(first + i) {
+			continue
+		}
+		sample, err := input.Samples.ToMap(first + i)
+		if err != nil {
+			return err
+		}
+		if filter.eval(sample) {
+			indexes = append(indexes, i)
+		}
+	}
+
+	input.Samples.indexes = indexes
+	if len(indexes) == 0 {
+		return ErrNoData
+	}
+	return nil
+}
+
+// TakeFiltered is a function to take DDS samples from the DDS DataReader and
+// only expose the valid samples matching a filter expression, for example:
+//
+//	input.TakeFiltered("l > %0 AND st = 'test'", []string{"50"})
+//
+// The filter is evaluated in Go, so all the samples are removed from the
+// DDS DataReader's receive queue, including the ones that do not match.
+// It returns ErrNoData when no sample matches.
+func (input *Input) TakeFiltered(expression string, params []string) (err error) {
+	filter, err := parseFilter(expression, params)
+	if err != nil {
+		return err
+	}
+	err = input.Take()
+	if err != nil {
+		return err
+	}
+	return input.applyFilter(filter)
+}
+
+// ReadFiltered is a function to read DDS samples from the DDS DataReader and
+// only expose the valid samples matching a filter expression.
+// See TakeFiltered for the syntax of the expression.
+// It returns ErrNoData when no sample matches.
+func (input *Input) ReadFiltered(expression string, params []string) (err error) {
+	filter, err := parseFilter(expression, params)
+	if err != nil {
+		return err
+	}
+	err = input.Read()
+	if err != nil {
+		return err
+	}
+	return input.applyFilter(filter)
+}
@@ -0,0 +1,99 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"iter"
+)
+
+// SampleIterator iterates over the samples of an input after a Read or Take.
+// Use it as:
+//
+//	it := input.Iterate()
+//	for it.Next() {
+//		if it.Valid() {
+//			st := it.Samples().GetString(it.Index(), "st")
+//		}
+//	}
+type SampleIterator struct {
+	input  *Input
+	index  int
+	length int
+}
+
+// Iterate returns an iterator over the samples of the last Read or Take
+func (input *Input) Iterate() (it *SampleIterator) {
+	it = new(SampleIterator)
+	it.input = input
+	it.index = -1
+	if input.check() == nil {
+		it.length = input.Samples.GetLength()
+	}
+	return it
+}
+
+// Next advances the iterator to the next sample and reports whether there is one
+func (it *SampleIterator) Next() bool {
+	if it.index < it.length {
+		it.index++
+	}
+	return it.index < it.length
+}
+
+// Index returns the index of the current sample, to be used with Samples and Infos
+func (it *SampleIterator) Index() int {
+	if it.input == nil {
+		return it.index
+	}
+	return it.input.Samples.firstIndex() + it.index
+}
+
+// Valid reports whether the current sample contains valid data
+func (it *SampleIterator) Valid() bool {
+	if it.input == nil {
+		return false
+	}
+	return it.input.Infos.IsValid(it.Index())
+}
+
+// Samples returns the samples the iterator is iterating over
+func (it *SampleIterator) Samples() *Samples {
+	if it.input == nil {
+		return nil
+	}
+	return it.input.Samples
+}
+
+// Infos returns the infos the iterator is iterating over
+func (it *SampleIterator) Infos() *Infos {
+	if it.input == nil {
+		return nil
+	}
+	return it.input.Infos
+}
+
+// All returns an iterator over the index and validity of each sample, as in:
+//
+//	for i, valid := range input.Samples.All() {
+//	}
+func (samples *Samples) All() iter.Seq2[int, bool] {
+	return func(yield func(int, bool) bool) {
+		if samples.check() != nil {
+			return
+		}
+		first := samples.firstIndex()
+		for i := first; i < first+samples.GetLength(); i++ {
+			if !yield(i, samples.input.Infos.IsValid(i)) {
+				return
+			}
+		}
+	}
+}
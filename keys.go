@@ -0,0 +1,126 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// KeyJSON is a function to build the JSON of the key of a Go value, made of
+// the fields of its struct tagged with `dds:"key"`, for example:
+//
+//	type Test struct {
+//		St string `json:"st" dds:"key"`
+//		L  int32  `json:"l"`
+//	}
+//
+// The members are named after the json tags, as with Instance.Set. The tag
+// should match the members declared with key="true" in the XML type.
+func KeyJSON(v interface{}) (jsonKey []byte, err error) {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() {
+		err = errors.New("Value is null")
+		return nil, err
+	}
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			err = errors.New("Value is null")
+			return nil, err
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		err = errors.New("Not a struct: " + value.Type().String())
+		return nil, err
+	}
+
+	// Build the JSON by hand to keep the order of the fields of the struct
+	buffer := []byte{'{'}
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() || !hasTagOption(field.Tag.Get("dds"), "key") {
+			continue
+		}
+
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if jsonName, _, _ := strings.Cut(tag, ","); jsonName != "" {
+				name = jsonName
+			}
+		}
+		jsonName, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		jsonValue, err := getCodec().Marshal(value.Field(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		if len(buffer) > 1 {
+			buffer = append(buffer, ',')
+		}
+		buffer = append(buffer, jsonName...)
+		buffer = append(buffer, ':')
+		buffer = append(buffer, jsonValue...)
+	}
+	if len(buffer) == 1 {
+		err = errors.New("No key field in " + typ.String())
+		return nil, err
+	}
+	return append(buffer, '}'), nil
+}
+
+// hasTagOption reports whether a comma-separated struct tag holds an option
+func hasTagOption(tag string, option string) bool {
+	for _, name := range strings.Split(tag, ",") {
+		if name == option {
+			return true
+		}
+	}
+	return false
+}
+
+// DisposeInstance is a function to dispose the instance with the key of a Go
+// value (see KeyJSON). Only the key members are set before the write.
+func (output *Output) DisposeInstance(v interface{}) error {
+	return output.writeKey(v, WriteParams{}.WithDispose())
+}
+
+// UnregisterInstance is a function to unregister the instance with the key of
+// a Go value (see KeyJSON). Only the key members are set before the write.
+func (output *Output) UnregisterInstance(v interface{}) error {
+	return output.writeKey(v, WriteParams{}.WithUnregister())
+}
+
+// writeKey sets the key of a Go value and writes it with the parameters
+func (output *Output) writeKey(v interface{}, params WriteParams) error {
+	err := output.check()
+	if err != nil {
+		return err
+	}
+
+	jsonKey, err := KeyJSON(v)
+	if err != nil {
+		return err
+	}
+	err = output.Instance.SetJSON(jsonKey)
+	if err != nil {
+		return err
+	}
+	return output.WriteWithParamsStruct(params)
+}
@@ -0,0 +1,89 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+// Package libpath locates the native RTI Connector library for the running platform
+package libpath
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// platforms maps GOOS/GOARCH to the directories under rticonnextdds-connector/lib,
+// matching the cgo LDFLAGS of the rti package
+var platforms = map[string]string{
+	"darwin/amd64":  "x64Darwin16clang8.0",
+	"linux/386":     "i86Linux3.xgcc4.6.3",
+	"linux/amd64":   "x64Linux2.6gcc4.4.5",
+	"linux/arm":     "armv6vfphLinux3.xgcc4.7.2",
+	"windows/386":   "i86Win32VS2010",
+	"windows/amd64": "x64Win64VS2013",
+}
+
+// libraryNames maps GOOS to the file name of the native library
+var libraryNames = map[string]string{
+	"darwin":  "librtiddsconnector.dylib",
+	"linux":   "librtiddsconnector.so",
+	"windows": "rtiddsconnector.dll",
+}
+
+// Platform returns the name of the library directory for the running platform
+// (e.g. "x64Linux2.6gcc4.4.5")
+func Platform() (platform string, err error) {
+	platform, ok := platforms[runtime.GOOS+"/"+runtime.GOARCH]
+	if !ok {
+		err = errors.New("Unsupported platform: " + runtime.GOOS + "/" + runtime.GOARCH)
+		return "", err
+	}
+	return platform, nil
+}
+
+// Locate returns the directory containing the native library for the running
+// platform, to be added to LD_LIBRARY_PATH, DYLD_LIBRARY_PATH or PATH.
+// It looks for rticonnextdds-connector/lib/<platform> under the directory
+// given by the RTI_CONNECTOR_HOME environment variable, the root of this
+// module and the current working directory, in that order.
+func Locate() (dir string, err error) {
+	platform, err := Platform()
+	if err != nil {
+		return "", err
+	}
+	return locate(searchRoots(), platform, libraryNames[runtime.GOOS])
+}
+
+func searchRoots() (roots []string) {
+	if home := os.Getenv("RTI_CONNECTOR_HOME"); home != "" {
+		roots = append(roots, home)
+	}
+	if _, file, _, ok := runtime.Caller(0); ok {
+		roots = append(roots, filepath.Dir(filepath.Dir(file)))
+	}
+	if wd, err := os.Getwd(); err == nil {
+		roots = append(roots, wd)
+	}
+	return roots
+}
+
+func locate(roots []string, platform string, libraryName string) (dir string, err error) {
+	for _, root := range roots {
+		for _, candidate := range []string{
+			filepath.Join(root, "rticonnextdds-connector", "lib", platform),
+			filepath.Join(root, "lib", platform),
+		} {
+			if _, err := os.Stat(filepath.Join(candidate, libraryName)); err == nil {
+				return candidate, nil
+			}
+		}
+	}
+	err = errors.New("Library " + libraryName + " not found for platform " + platform)
+	return "", err
+}
@@ -0,0 +1,39 @@
+package libpath
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlatform(t *testing.T) {
+	platform, err := Platform()
+	if err != nil {
+		t.Skip(err)
+	}
+	assert.NotEqual(t, platform, "")
+}
+
+func TestLocate(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "rticonnextdds-connector", "lib", "x64Linux2.6gcc4.4.5")
+	assert.Nil(t, os.MkdirAll(dir, 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "librtiddsconnector.so"), nil, 0644))
+
+	found, err := locate([]string{t.TempDir(), root}, "x64Linux2.6gcc4.4.5", "librtiddsconnector.so")
+	assert.Nil(t, err)
+	assert.Equal(t, found, dir)
+
+	_, err = locate([]string{root}, "x64Darwin16clang8.0", "librtiddsconnector.dylib")
+	assert.NotNil(t, err)
+}
+
+func TestLocateModule(t *testing.T) {
+	if _, err := Platform(); err != nil {
+		t.Skip(err)
+	}
+	dir, err := Locate()
+	assert.Nil(t, err)
+	assert.NotEqual(t, dir, "")
+}
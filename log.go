@@ -0,0 +1,74 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"sync"
+)
+
+// LogLevel is the severity of a message passed to a LogHandler
+type LogLevel int
+
+// The severities of the messages, from the most to the least severe
+const (
+	LogSilent LogLevel = iota - 1 // only used as a verbosity, to report nothing
+	LogError
+	LogWarning
+	LogInfo
+)
+
+var logLevelNames = [...]string{"ERROR", "WARNING", "INFO"}
+
+// String returns the name of the level
+func (level LogLevel) String() string {
+	if level < 0 || int(level) >= len(logLevelNames) {
+		return "UNKNOWN"
+	}
+	return logLevelNames[level]
+}
+
+// LogHandler is called with the errors reported by the C layer
+type LogHandler func(level LogLevel, msg string)
+
+var (
+	logMutex   sync.RWMutex
+	logHandler LogHandler
+)
+
+// SetLogHandler registers a function that is called with the errors reported
+// by the C layer (e.g. a failed wait or an invalid configuration).
+// The C layer does not expose its own logging, so only the errors that the
+// Go wrapper sees are reported. Pass nil to remove the handler.
+func SetLogHandler(handler LogHandler) {
+	logMutex.Lock()
+	defer logMutex.Unlock()
+	logHandler = handler
+}
+
+// log passes a message to the registered handler when it is
+// at least as severe as the verbosity of the connector
+func (connector *Connector) log(level LogLevel, msg string) {
+	if level > connector.options.Verbosity {
+		return
+	}
+	logMessage(level, msg)
+}
+
+// logMessage passes a message to the registered handler, if any
+func logMessage(level LogLevel, msg string) {
+	logMutex.RLock()
+	handler := logHandler
+	logMutex.RUnlock()
+
+	if handler != nil {
+		handler(level, msg)
+	}
+}
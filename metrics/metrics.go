@@ -0,0 +1,80 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+// Package metrics exports the Stats of a connector as Prometheus metrics.
+// It is a separate package so that the rti package does not depend on Prometheus.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rticommunity/rticonnextdds-connector-go"
+)
+
+// Collector is a prometheus.Collector reading the Stats of a connector created
+// with the EnableStats option
+type Collector struct {
+	connector *rti.Connector
+
+	samplesWritten *prometheus.Desc
+	samplesTaken   *prometheus.Desc
+	samplesRead    *prometheus.Desc
+	writesFailed   *prometheus.Desc
+	takesFailed    *prometheus.Desc
+	inputTaken     *prometheus.Desc
+}
+
+// NewCollector is a constructor of Collector. The metrics are labelled with the
+// participant of the connector, and the samples taken are also reported for
+// each input that took some, labelled with the name of the input. Only the
+// counters maintained by the connector are read, so collecting the metrics
+// does not interfere with the goroutines using the inputs and outputs.
+func NewCollector(connector *rti.Connector) *Collector {
+	labels := prometheus.Labels{"participant": connector.ParticipantName()}
+	return &Collector{
+		connector: connector,
+
+		samplesWritten: prometheus.NewDesc("rti_connector_samples_written_total",
+			"Number of samples written.", nil, labels),
+		samplesTaken: prometheus.NewDesc("rti_connector_samples_taken_total",
+			"Number of samples taken.", nil, labels),
+		samplesRead: prometheus.NewDesc("rti_connector_samples_read_total",
+			"Number of samples read.", nil, labels),
+		writesFailed: prometheus.NewDesc("rti_connector_writes_failed_total",
+			"Number of writes that returned an error.", nil, labels),
+		takesFailed: prometheus.NewDesc("rti_connector_takes_failed_total",
+			"Number of takes and reads that returned an error.", nil, labels),
+		inputTaken: prometheus.NewDesc("rti_connector_input_samples_taken_total",
+			"Number of samples taken by an input.", []string{"input"}, labels),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.samplesWritten
+	ch <- collector.samplesTaken
+	ch <- collector.samplesRead
+	ch <- collector.writesFailed
+	ch <- collector.takesFailed
+	ch <- collector.inputTaken
+}
+
+// Collect implements prometheus.Collector
+func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := collector.connector.Stats()
+	ch <- prometheus.MustNewConstMetric(collector.samplesWritten, prometheus.CounterValue, float64(stats.SamplesWritten))
+	ch <- prometheus.MustNewConstMetric(collector.samplesTaken, prometheus.CounterValue, float64(stats.SamplesTaken))
+	ch <- prometheus.MustNewConstMetric(collector.samplesRead, prometheus.CounterValue, float64(stats.SamplesRead))
+	ch <- prometheus.MustNewConstMetric(collector.writesFailed, prometheus.CounterValue, float64(stats.WritesFailed))
+	ch <- prometheus.MustNewConstMetric(collector.takesFailed, prometheus.CounterValue, float64(stats.TakesFailed))
+
+	for name, count := range stats.TakenByInput {
+		ch <- prometheus.MustNewConstMetric(collector.inputTaken, prometheus.CounterValue, float64(count), name)
+	}
+}
@@ -0,0 +1,61 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package metrics
+
+import (
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rticommunity/rticonnextdds-connector-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	_, curPath, _, _ := runtime.Caller(0)
+	xmlPath := path.Join(path.Dir(curPath), "../test/xml/Test.xml")
+	connector, err := rti.NewConnectorWithOptions("MyParticipantLibrary::Zero", xmlPath, rti.ConnectorOptions{EnableStats: true})
+	assert.Nil(t, err)
+	defer connector.Delete()
+	input, err := connector.GetInput("MySubscriber::MyReader")
+	assert.Nil(t, err)
+	output, err := connector.GetOutput("MyPublisher::MyWriter")
+	assert.Nil(t, err)
+
+	collector := NewCollector(connector)
+	descs := make(chan *prometheus.Desc, 10)
+	collector.Describe(descs)
+	close(descs)
+	assert.Equal(t, len(descs), 6)
+
+	// The samples taken by an input are only reported once it took some
+	metrics := make(chan prometheus.Metric, 10)
+	collector.Collect(metrics)
+	close(metrics)
+	assert.Equal(t, len(metrics), 5)
+
+	input.Take()
+	err = output.Write()
+	assert.Nil(t, err)
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	err = input.Take()
+	assert.Nil(t, err)
+	metrics = make(chan prometheus.Metric, 10)
+	collector.Collect(metrics)
+	close(metrics)
+	assert.Equal(t, len(metrics), 6)
+
+	registry := prometheus.NewRegistry()
+	err = registry.Register(collector)
+	assert.Nil(t, err)
+}
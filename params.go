@@ -0,0 +1,241 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Identity is the identity of a sample: the GUID of the DataWriter
+// that wrote it and its sequence number
+type Identity struct {
+	WriterGUID     [16]byte `json:"writer_guid"`
+	SequenceNumber int      `json:"sequence_number"`
+}
+
+// GUIDString returns the GUID of the DataWriter in dotted hexadecimal format,
+// e.g. "0101a8c0.00002d06.00000001.80000003"
+func (identity Identity) GUIDString() string {
+	words := make([]string, 4)
+	for i := range words {
+		words[i] = hex.EncodeToString(identity.WriterGUID[i*4 : i*4+4])
+	}
+	return strings.Join(words, ".")
+}
+
+// String returns the identity as the GUID of the DataWriter and the
+// sequence number separated by a colon, e.g. "0101a8c0.00002d06.00000001.80000003:42"
+func (identity Identity) String() string {
+	return identity.GUIDString() + ":" + strconv.Itoa(identity.SequenceNumber)
+}
+
+// ParseIdentity parses an identity in the format returned by Identity.String
+func ParseIdentity(s string) (identity Identity, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		err = errors.New("Invalid identity: " + s)
+		return identity, err
+	}
+
+	guid, err := hex.DecodeString(strings.Replace(parts[0], ".", "", -1))
+	if err != nil || len(guid) != len(identity.WriterGUID) || strings.Count(parts[0], ".") != 3 {
+		err = errors.New("Invalid GUID in identity: " + s)
+		return identity, err
+	}
+	copy(identity.WriterGUID[:], guid)
+
+	identity.SequenceNumber, err = strconv.Atoi(parts[1])
+	if err != nil {
+		err = errors.New("Invalid sequence number in identity: " + s)
+		return identity, err
+	}
+	return identity, nil
+}
+
+// identitySource assigns the identities of the samples written by an output
+// with Output.WriteJSONWithIdentity
+type identitySource struct {
+	guid           [16]byte
+	sequenceNumber atomic.Int64
+}
+
+// newIdentitySource returns an identity source with a random GUID
+func newIdentitySource() *identitySource {
+	source := new(identitySource)
+	rand.Read(source.guid[:])
+	return source
+}
+
+// WriteParams are the parameters of Output.WriteWith and
+// Output.WriteWithParamsStruct. They can be built with the With methods:
+//
+//	params := WriteParams{}.WithDispose().WithSourceTimestamp(time.Now())
+type WriteParams struct {
+	Action          string    // "write" (the default), "dispose" or "unregister"
+	SourceTimestamp time.Time // set by the middleware when zero
+	Identity        *Identity // set by the middleware when nil
+	RelatedIdentity *Identity // e.g. the identity of the request a reply answers
+}
+
+// WithSourceTimestamp returns a copy of the parameters with the source timestamp set
+func (params WriteParams) WithSourceTimestamp(timestamp time.Time) WriteParams {
+	params.SourceTimestamp = timestamp
+	return params
+}
+
+// WithDispose returns a copy of the parameters that disposes the instance
+func (params WriteParams) WithDispose() WriteParams {
+	params.Action = "dispose"
+	return params
+}
+
+// WithUnregister returns a copy of the parameters that unregisters the instance
+func (params WriteParams) WithUnregister() WriteParams {
+	params.Action = "unregister"
+	return params
+}
+
+// WithIdentity returns a copy of the parameters with the identity of the sample set
+func (params WriteParams) WithIdentity(identity Identity) WriteParams {
+	params.Identity = &identity
+	return params
+}
+
+// WithRelatedIdentity returns a copy of the parameters with the related sample identity set
+func (params WriteParams) WithRelatedIdentity(identity Identity) WriteParams {
+	params.RelatedIdentity = &identity
+	return params
+}
+
+// ToJSON returns the parameters in the JSON format expected by the C layer
+func (params WriteParams) ToJSON() (jsonParams []byte, err error) {
+	members := map[string]interface{}{}
+	if params.Action != "" {
+		members["action"] = params.Action
+	}
+	if !params.SourceTimestamp.IsZero() {
+		members["source_timestamp"] = params.SourceTimestamp.UnixNano()
+	}
+	if params.Identity != nil {
+		members["identity"] = params.Identity
+	}
+	if params.RelatedIdentity != nil {
+		members["related_sample_identity"] = params.RelatedIdentity
+	}
+	return json.Marshal(members)
+}
+
+// WriteWith is a function to set all the members of the instance from v
+// and write it with the given parameters
+func (output *Output) WriteWith(v interface{}, params WriteParams) (err error) {
+	if output == nil {
+		err = errors.New("Output is null")
+		return err
+	}
+
+	jsonParams, err := params.ToJSON()
+	if err != nil {
+		return err
+	}
+	err = output.Instance.Set(v)
+	if err != nil {
+		return err
+	}
+	return output.WriteWithParams(string(jsonParams))
+}
+
+// WriteWithParamsStruct is a function to write the DDS data instance
+// in an output with the given parameters
+func (output *Output) WriteWithParamsStruct(params WriteParams) (err error) {
+	jsonParams, err := params.ToJSON()
+	if err != nil {
+		return err
+	}
+	return output.WriteWithParams(string(jsonParams))
+}
+
+// WriteJSONWithIdentity is a function to set all the members of the instance
+// from a JSON sample and write it, returning the identity of the sample.
+// The C layer does not return the identity assigned by the middleware, so the
+// identity is assigned by the output instead and passed with the write: its
+// GUID is generated randomly when the output is obtained, and is not the GUID
+// of the DataWriter, and its sequence number is incremented by each call,
+// starting at 1. Readers receive it as the identity of the sample, so it can
+// be used to correlate requests and replies.
+func (output *Output) WriteJSONWithIdentity(jsonData string) (identity Identity, err error) {
+	err = output.check()
+	if err != nil {
+		return identity, err
+	}
+
+	identity.WriterGUID = output.identities.guid
+	identity.SequenceNumber = int(output.identities.sequenceNumber.Add(1))
+	err = output.Instance.SetJSON([]byte(jsonData))
+	if err != nil {
+		return Identity{}, err
+	}
+	err = output.WriteWithParamsStruct(WriteParams{}.WithIdentity(identity))
+	if err != nil {
+		return Identity{}, err
+	}
+	return identity, nil
+}
+
+// WriteRequest is a sample to write with Output.WriteManyWithParams
+type WriteRequest struct {
+	Value  interface{} // the sample, which sets all the members of the instance
+	Params WriteParams // the parameters of the write, e.g. a distinct identity
+}
+
+// WriteManyWithParams is a function to write several samples, each with its
+// own parameters, in order. All the samples and parameters are converted to
+// JSON before anything is written, so an invalid request leaves all of them
+// unwritten.
+func (output *Output) WriteManyWithParams(requests []WriteRequest) (err error) {
+	if output == nil {
+		err = errors.New("Output is null")
+		return err
+	}
+
+	jsonValues := make([][]byte, len(requests))
+	jsonParams := make([]string, len(requests))
+	codec := getCodec()
+	for i, request := range requests {
+		jsonValues[i], err = codec.Marshal(request.Value)
+		if err != nil {
+			return err
+		}
+		params, err := request.Params.ToJSON()
+		if err != nil {
+			return err
+		}
+		jsonParams[i] = string(params)
+	}
+
+	for i := range requests {
+		err = output.Instance.SetJSON(jsonValues[i])
+		if err != nil {
+			return err
+		}
+		err = output.WriteWithParams(jsonParams[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// Player writes the samples of a recording made by a Recorder to an output,
+// with the intervals between the times they were taken. These are local
+// times of the recording application: the C layer does not return the source
+// timestamps of the samples, so the original timing can only be approximated.
+type Player struct {
+	Speed   float64 // multiplier of the pace of the recording, 1 when zero
+	Loop    bool    // replay the recording until the context is done, see Play
+	output  *Output
+	records []Record
+}
+
+// NewPlayer is a function to create a player for the recording read from r.
+// The whole recording is read, so that it can be replayed in a loop.
+func NewPlayer(r io.Reader, output *Output) (player *Player, err error) {
+	err = output.check()
+	if err != nil {
+		return nil, err
+	}
+
+	player = &Player{output: output}
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var record Record
+		err = decoder.Decode(&record)
+		if err == io.EOF {
+			return player, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record.Data) == 0 {
+			err = errors.New("Record without data")
+			return nil, err
+		}
+		player.records = append(player.records, record)
+	}
+}
+
+// Len is a function to retrieve the number of samples in the recording
+func (player *Player) Len() int {
+	if player == nil {
+		return 0
+	}
+	return len(player.records)
+}
+
+// Play is a function to write the samples of the recording, waiting between
+// two samples for the interval between the times they were taken divided by
+// Speed. The samples get new source timestamps from the middleware, as with
+// Replay. Play returns when the recording is over, or the error of the
+// context when it is done. A nil context never gets done.
+// With Loop, each pass lasts as long as the recording, so a recording whose
+// samples were all taken at the same time cannot be looped.
+func (player *Player) Play(ctx context.Context) (err error) {
+	if player == nil {
+		err = errors.New("Player is null")
+		return err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	speed := player.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	if speed < 0 {
+		err = errors.New("Invalid speed: the speed must be positive")
+		return err
+	}
+	if player.Loop && (len(player.records) == 0 || player.records[len(player.records)-1].TakenAt <= player.records[0].TakenAt) {
+		err = errors.New("Cannot loop over a recording without duration")
+		return err
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+	for {
+		start := time.Now()
+		for i := range player.records {
+			record := &player.records[i]
+			offset := time.Duration(float64(record.TakenAt-player.records[0].TakenAt) / speed)
+			if delay := time.Until(start.Add(offset)); delay > 0 {
+				timer.Reset(delay)
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-timer.C:
+				}
+			} else if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			err = player.output.replay(record)
+			if err != nil {
+				return err
+			}
+		}
+		if !player.Loop {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,220 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"encoding/xml"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// qosOverridePaths are the QoS policies that can be overridden with
+// ConnectorOptions.QoSOverrides, relative to datareader_qos or datawriter_qos,
+// in the order they are set
+var qosOverridePaths = []string{
+	"history/kind",
+	"history/depth",
+	"reliability/kind",
+	"durability/kind",
+}
+
+// xmlNode is a generic XML element, used to edit the XML configuration
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Text     string     `xml:",chardata"`
+	Children []*xmlNode `xml:",any"`
+}
+
+// child returns the first child element named name, appending it if needed
+func (node *xmlNode) child(name string) *xmlNode {
+	for _, child := range node.Children {
+		if child.XMLName.Local == name {
+			return child
+		}
+	}
+	child := &xmlNode{XMLName: xml.Name{Local: name}}
+	node.Children = append(node.Children, child)
+	return child
+}
+
+// firstChild returns the first child element named name, inserting it
+// before the other children if needed, as the XML schema expects for the
+// QoS of a publisher or a subscriber
+func (node *xmlNode) firstChild(name string) *xmlNode {
+	for _, child := range node.Children {
+		if child.XMLName.Local == name {
+			return child
+		}
+	}
+	child := &xmlNode{XMLName: xml.Name{Local: name}}
+	node.Children = append([]*xmlNode{child}, node.Children...)
+	return child
+}
+
+// attr returns the value of the attribute named name
+func (node *xmlNode) attr(name string) string {
+	for _, attr := range node.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// checkQoSOverrides returns an error for the first unsupported override
+func checkQoSOverrides(overrides map[string]string) (err error) {
+	for path := range overrides {
+		names := strings.SplitN(path, "/", 2)
+		if len(names) != 2 || (names[0] != "datareader_qos" && names[0] != "datawriter_qos") || !slices.Contains(qosOverridePaths, names[1]) {
+			err = errors.New("Unsupported QoS override: " + path)
+			return err
+		}
+	}
+	return nil
+}
+
+// overrideQoS returns a str:// URL of the XML documents referenced by url,
+// merged into a single document in which the QoS overrides are set inline
+// on the DataReaders and DataWriters of the participant configName, and the
+// partitions on its publishers and subscribers
+func overrideQoS(url string, configName string, overrides map[string]string, partitions map[string][]string) (overriddenURL string, err error) {
+	err = checkQoSOverrides(overrides)
+	if err != nil {
+		return "", err
+	}
+	names := strings.Split(configName, "::")
+	if len(names) != 2 {
+		err = errors.New("Invalid participant profile: " + configName)
+		return "", err
+	}
+
+	documents, err := loadDocuments(url)
+	if err != nil {
+		return "", err
+	}
+	merged, err := mergeDocuments(documents)
+	if err != nil {
+		return "", err
+	}
+
+	var participant *xmlNode
+	for _, library := range merged.Children {
+		if library.XMLName.Local != "domain_participant_library" || library.attr("name") != names[0] {
+			continue
+		}
+		for _, node := range library.Children {
+			if node.XMLName.Local == "domain_participant" && node.attr("name") == names[1] {
+				participant = node
+			}
+		}
+	}
+	if participant == nil {
+		err = errors.New("Participant profile not found: " + configName)
+		return "", err
+	}
+
+	partitioned := 0
+	for _, group := range participant.Children {
+		entity := ""
+		switch group.XMLName.Local {
+		case "publisher":
+			entity = "data_writer"
+		case "subscriber":
+			entity = "data_reader"
+		default:
+			continue
+		}
+		if names, ok := partitions[group.attr("name")]; ok {
+			partition := group.firstChild(group.XMLName.Local + "_qos").child("partition").child("name")
+			partition.Children = nil
+			for _, name := range names {
+				partition.Children = append(partition.Children, &xmlNode{XMLName: xml.Name{Local: "element"}, Text: name})
+			}
+			partitioned++
+		}
+		qosName := strings.Replace(entity, "_", "", 1) + "_qos"
+		for _, node := range group.Children {
+			if node.XMLName.Local != entity {
+				continue
+			}
+			for _, path := range qosOverridePaths {
+				value, ok := overrides[qosName+"/"+path]
+				if !ok {
+					continue
+				}
+				policy := node.child(qosName)
+				for _, name := range strings.Split(path, "/") {
+					policy = policy.child(name)
+				}
+				policy.Text = value
+			}
+		}
+	}
+
+	if partitioned != len(partitions) {
+		for name := range partitions {
+			if !hasGroup(participant, name) {
+				err = errors.New("Publisher or subscriber not found: " + name)
+				return "", err
+			}
+		}
+	}
+
+	cleanNodes(merged)
+	document, err := xml.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+	return inlineURL(string(document)), nil
+}
+
+// mergeDocuments merges the elements of the XML documents under a single dds element
+func mergeDocuments(documents [][]byte) (merged *xmlNode, err error) {
+	merged = &xmlNode{XMLName: xml.Name{Local: "dds"}}
+	for _, document := range documents {
+		root := new(xmlNode)
+		err = xml.Unmarshal(document, root)
+		if err != nil {
+			return nil, err
+		}
+		merged.Children = append(merged.Children, root.Children...)
+	}
+	return merged, nil
+}
+
+// hasGroup reports whether a participant has a publisher or a subscriber named name
+func hasGroup(participant *xmlNode, name string) bool {
+	for _, group := range participant.Children {
+		if (group.XMLName.Local == "publisher" || group.XMLName.Local == "subscriber") && group.attr("name") == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanNodes trims the text of the elements, since encoding/xml escapes the
+// indentation, and removes the attributes with a namespace (e.g. the XML
+// schema location), which encoding/xml cannot write back as they were
+func cleanNodes(node *xmlNode) {
+	node.Text = strings.TrimSpace(node.Text)
+	attrs := node.Attrs[:0]
+	for _, attr := range node.Attrs {
+		if attr.Name.Space == "" {
+			attrs = append(attrs, attr)
+		}
+	}
+	node.Attrs = attrs
+	for _, child := range node.Children {
+		cleanNodes(child)
+	}
+}
@@ -0,0 +1,151 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// Record is a sample written by a Recorder, one JSON object per line
+type Record struct {
+	Input   string          `json:"input"`         // name of the input that took the sample
+	TakenAt int64           `json:"taken_at"`      // local time of the take in nanoseconds since the epoch
+	Key     json.RawMessage `json:"key,omitempty"` // key members, see Samples.GetKeyValue
+	Data    json.RawMessage `json:"data"`
+}
+
+// Recorder writes the samples taken by the inputs passed to Input.RecordTo
+// as JSON lines. The C layer does not return the source timestamps of the
+// samples, so they are not recorded: each record holds the local time of the
+// take instead, which is not the time the sample was written.
+type Recorder struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+// NewRecorder is a function to create a recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{encoder: json.NewEncoder(w)}
+}
+
+// Err is a function to retrieve the first error returned by the writer of
+// the recorder. Nothing is written after it.
+func (recorder *Recorder) Err() error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return recorder.err
+}
+
+// write writes a record, unless a previous write failed
+func (recorder *Recorder) write(record *Record) error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	if recorder.err != nil {
+		return recorder.err
+	}
+	recorder.err = recorder.encoder.Encode(record)
+	return recorder.err
+}
+
+// RecordTo is a function to write every valid sample taken by the input to
+// a recorder. Several inputs can share a recorder. Pass nil to stop recording.
+func (input *Input) RecordTo(recorder *Recorder) error {
+	err := input.check()
+	if err != nil {
+		return err
+	}
+	input.recorder.Store(recorder)
+	return nil
+}
+
+// record writes the valid samples of a take to the recorder of the input, if any
+func (input *Input) record() {
+	if input.recorder == nil {
+		return
+	}
+	recorder := input.recorder.Load()
+	if recorder == nil {
+		return
+	}
+
+	takenAt := time.Now().UnixNano()
+	first := input.Samples.firstIndex()
+	for i := first; i < first+input.Samples.GetLength(); i++ {
+		if !input.Infos.IsValid(i) {
+			continue
+		}
+		record := Record{Input: input.name, TakenAt: takenAt}
+		jsonData, err := input.Samples.GetJSON(i)
+		if err != nil {
+			input.connector.log(LogError, "Cannot record a sample of "+input.name+": "+err.Error())
+			continue
+		}
+		record.Data = jsonData
+		// The key is left out when the type is not found in the XML configuration
+		jsonKey, err := input.Samples.GetKeyValue(i)
+		if err == nil && jsonKey != "{}" {
+			record.Key = json.RawMessage(jsonKey)
+		}
+		err = recorder.write(&record)
+		if err != nil {
+			input.connector.log(LogError, "Cannot record a sample of "+input.name+": "+err.Error())
+			return
+		}
+	}
+}
+
+// Replay is a function to write the samples of a recording to an output, in
+// order and without pacing. It returns the number of samples written.
+// The recording does not hold the source timestamps of the samples (see
+// Recorder), so the replayed samples get new source timestamps from the
+// middleware: the original ones cannot be preserved with this C layer.
+func Replay(r io.Reader, output *Output) (count int, err error) {
+	err = output.check()
+	if err != nil {
+		return 0, err
+	}
+
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var record Record
+		err = decoder.Decode(&record)
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		err = output.replay(&record)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// replay writes a recorded sample
+func (output *Output) replay(record *Record) (err error) {
+	if len(record.Data) == 0 {
+		err = errors.New("Record without data")
+		return err
+	}
+	err = output.Instance.SetJSON(record.Data)
+	if err != nil {
+		return err
+	}
+	return output.Write()
+}
@@ -21,29 +21,222 @@ package rti
 // #cgo linux,arm LDFLAGS: -L${SRCDIR}/rticonnextdds-connector/lib/armv6vfphLinux3.xgcc4.7.2 -lrtiddsconnector -ldl -lnsl -lm -lpthread -lrt
 // #include "rticonnextdds-connector.h"
 // #include <stdlib.h>
+// #include <string.h>
 import "C"
+import "bytes"
+import "context"
 import "errors"
+import "fmt"
+import "io"
+import "runtime"
+import "strconv"
+import "strings"
+import "sync"
+import "sync/atomic"
+import "time"
 import "unsafe"
 import "encoding/json"
 
+/*********
+* Errors *
+*********/
+
+// ErrTimeout is returned when a wait operation times out
+var ErrTimeout = errors.New("Timeout")
+
+// DefaultTimeout is passed as the timeout of Wait, WaitAny and TakeTimeout
+// to wait for the default timeout of the connector (see
+// ConnectorOptions.DefaultWaitTimeout) instead of an explicit timeout
+const DefaultTimeout = -2
+
+// ErrNoData is returned by Read and Take when there are no samples.
+// It is returned as is, so it can be compared with == or errors.Is.
+var ErrNoData = errors.New("No data")
+
+// DDS return codes reported in DDSError.Code
+const (
+	RetcodeOk                 = 0
+	RetcodeError              = 1
+	RetcodeUnsupported        = 2
+	RetcodeBadParameter       = 3
+	RetcodePreconditionNotMet = 4
+	RetcodeOutOfResources     = 5
+	RetcodeNotEnabled         = 6
+	RetcodeImmutablePolicy    = 7
+	RetcodeInconsistentPolicy = 8
+	RetcodeAlreadyDeleted     = 9
+	RetcodeTimeout            = 10
+	RetcodeNoData             = 11
+	RetcodeIllegalOperation   = 12
+)
+
+var retcodeNames = [...]string{
+	"DDS_RETCODE_OK",
+	"DDS_RETCODE_ERROR",
+	"DDS_RETCODE_UNSUPPORTED",
+	"DDS_RETCODE_BAD_PARAMETER",
+	"DDS_RETCODE_PRECONDITION_NOT_MET",
+	"DDS_RETCODE_OUT_OF_RESOURCES",
+	"DDS_RETCODE_NOT_ENABLED",
+	"DDS_RETCODE_IMMUTABLE_POLICY",
+	"DDS_RETCODE_INCONSISTENT_POLICY",
+	"DDS_RETCODE_ALREADY_DELETED",
+	"DDS_RETCODE_TIMEOUT",
+	"DDS_RETCODE_NO_DATA",
+	"DDS_RETCODE_ILLEGAL_OPERATION",
+}
+
+// DDSError is an error returned by the C layer with its DDS return code
+type DDSError struct {
+	Code    int    // one of the Retcode constants
+	Message string // the operation that failed
+}
+
+// Error returns the message and the name of the return code
+func (e *DDSError) Error() string {
+	name := "DDS_RETCODE_" + strconv.Itoa(e.Code)
+	if e.Code >= 0 && e.Code < len(retcodeNames) {
+		name = retcodeNames[e.Code]
+	}
+	return e.Message + ": " + name
+}
+
+// Is reports whether the error matches ErrTimeout or ErrNoData,
+// so that errors.Is works with the sentinel errors
+func (e *DDSError) Is(target error) bool {
+	switch target {
+	case ErrTimeout:
+		return e.Code == RetcodeTimeout
+	case ErrNoData:
+		return e.Code == RetcodeNoData
+	}
+	return false
+}
+
+// checkRetcode converts a DDS return code into an error.
+// Timeouts and no data are returned as ErrTimeout and ErrNoData.
+func checkRetcode(retcode int, message string) error {
+	switch retcode {
+	case RetcodeOk:
+		return nil
+	case RetcodeTimeout:
+		return ErrTimeout
+	case RetcodeNoData:
+		return ErrNoData
+	}
+	return &DDSError{Code: retcode, Message: message}
+}
+
+// waitSliceMs is the longest time WaitWithContext blocks in the C layer
+// before checking the context again
+const waitSliceMs = 50
+
+// maxExactInteger is the largest integer up to which all integers are
+// exactly represented by a double
+const maxExactInteger = 1 << 53
+
+// maxWaitPause is the longest pause between two waits of an input when the
+// data available belongs to another input, see takeWithContext
+const maxWaitPause = 16 * time.Millisecond
+
 /********
 * Types *
 *********/
 
+// ConnectorOptions are the options of NewConnectorWithOptions.
+// The zero value gives the same behavior as NewConnector.
+type ConnectorOptions struct {
+	// Verbosity is the least severe level of the messages of this connector
+	// passed to the handler registered with SetLogHandler. The default,
+	// LogError, only reports errors. LogSilent reports nothing.
+	Verbosity LogLevel
+
+	// OneBasedIndex makes the indexes of Samples and Infos start at 1,
+	// as in the C layer, instead of 0.
+	OneBasedIndex bool
+
+	// DisableOnDataEvent disables the on-data-available events of the C layer
+	// (onDataEventEnabled in RTIDDSConnectorConfiguration). Wait, Stream and the
+	// Subscribe functions rely on these events, so they no longer wake up on data.
+	DisableOnDataEvent bool
+
+	// QoSOverrides sets QoS policies of the DataReaders and DataWriters of the
+	// participant on top of the XML configuration. The keys are paths of a
+	// policy under datareader_qos or datawriter_qos, and the values are set
+	// as the XML text of the policy, for example:
+	//  "datareader_qos/history/kind":  "KEEP_LAST_HISTORY_QOS"
+	//  "datareader_qos/history/depth": "10"
+	// The supported policies are history/kind, history/depth, reliability/kind
+	// and durability/kind. The overrides are set inline on each entity of the
+	// participant, so the configuration is passed to the C layer as a single
+	// str:// document, without the XML comments.
+	QoSOverrides map[string]string
+
+	// Partitions sets the partition QoS of publishers and subscribers of the
+	// participant, by name (e.g. "MyPublisher"), on top of the XML
+	// configuration. Their DataWriters and DataReaders only communicate with
+	// those of the publishers and subscribers sharing a partition. As with
+	// QoSOverrides, the configuration is passed to the C layer as a single
+	// str:// document. The C layer cannot change the partitions at runtime.
+	Partitions map[string][]string
+
+	// EnableStats counts the samples written, taken and read, and the failures,
+	// as returned by Connector.Stats. Without it, the counters are not updated.
+	EnableStats bool
+
+	// ExpandEnv replaces the ${NAME} references in the XML configuration with
+	// the values of the environment variables, empty if not set, e.g.
+	// <domain name="MyDomain" domain_id="${DOMAIN_ID}">. As with QoSOverrides,
+	// the configuration is passed to the C layer as a single str:// document.
+	ExpandEnv bool
+
+	// DefaultWaitTimeout is the timeout used when DefaultTimeout is passed to
+	// Wait, WaitAny or TakeTimeout. Zero or negative waits forever.
+	// It can be changed with Connector.SetDefaultWaitTimeout.
+	DefaultWaitTimeout time.Duration
+
+	// ValidateJSON checks the JSON given to Instance.SetJSON, and so to the
+	// functions based on it such as Instance.Set, with Instance.ValidateJSON
+	// before passing it to the C layer
+	ValidateJSON bool
+}
+
 // Connector is a container managing DDS inputs and outputs
 type Connector struct {
-	native  *C.struct_RTIDDSConnector
-	Inputs  []Input
-	Outputs []Output
+	native       *C.struct_RTIDDSConnector
+	Inputs       []Input
+	Outputs      []Output
+	configName   string                                 // participant profile given to NewConnector
+	url          string                                 // location of the XML documents given to NewConnector
+	configs      []*xmlDDS                              // XML documents parsed on demand, see config()
+	configMutex  sync.Mutex                             // guards configs
+	options      ConnectorOptions                       // options given to NewConnectorWithOptions
+	stats        *connectorStats                        // nil without the EnableStats option
+	handle       *nativeHandle                          // memory allocated in C, released by Delete or the finalizer
+	done         chan struct{}                          // closed when the connector is deleted
+	streams      sync.WaitGroup                         // goroutines started by Input.Stream
+}
+
+// nativeHandle holds the memory allocated in C for a connector. It has no
+// pointer back to the connector, which its inputs and outputs point to, so
+// that its finalizer runs once the connector is unreachable: the Go runtime
+// does not run the finalizers of objects in a cycle.
+type nativeHandle struct {
+	native     *C.struct_RTIDDSConnector
+	config     *C.struct_RTIDDSConnectorConfiguration // C configuration, nil for the defaults
+	names      []*C.char                              // names of the inputs and outputs
+	configName string                                 // for the trace
 }
 
 // Output publishes DDS data
 type Output struct {
-	native    unsafe.Pointer // a pointer to a native DataWriter
-	connector *Connector
-	name      string // name of the native DataWriter
-	nameCStr  *C.char
-	Instance  *Instance
+	native     unsafe.Pointer // a pointer to a native DataWriter
+	connector  *Connector
+	name       string // name of the native DataWriter
+	nameCStr   *C.char
+	Instance   *Instance
+	identities *identitySource // identities of the samples written with WriteJSONWithIdentity
+	members    *sync.Map       // XML definitions of the members of the type, see member()
 }
 
 // Instance is used by an output to write DDS data
@@ -59,11 +252,15 @@ type Input struct {
 	nameCStr  *C.char
 	Samples   *Samples
 	Infos     *Infos
+	pending   [][]byte                  // JSON of the valid samples taken by NextContext and not returned yet
+	lastTaken *atomic.Int64             // time in nanoseconds of the last take of a valid sample
+	recorder  *atomic.Pointer[Recorder] // set by RecordTo, nil when not recording
 }
 
 // Samples is a sequence of data samples used by an input to read DDS data
 type Samples struct {
-	input *Input
+	input   *Input
+	indexes []int // indexes of the samples exposed after a filtered read or take, nil to expose all
 }
 
 // Infos is a sequence of info samples used by an input to read DDS meta data
@@ -93,14 +290,21 @@ func newOutput(connector *Connector, outputName string) (output *Output, err err
 	output.connector = connector
 
 	output.nameCStr = C.CString(outputName)
+	connector.handle.names = append(connector.handle.names, output.nameCStr)
 
 	output.native = C.RTIDDSConnector_getWriter(unsafe.Pointer(connector.native), output.nameCStr)
+	if traceEnabled.Load() {
+		traceCall("RTIDDSConnector_getWriter", strconv.Quote(outputName), nativeResult(output.native != nil))
+	}
 	if output.native == nil {
 		err = errors.New("Invalid Publication::DataWriter name")
+		connector.log(LogError, err.Error()+": "+outputName)
 		return nil, err
 	}
 	output.name = outputName
 	output.Instance = newInstance(output)
+	output.identities = newIdentitySource()
+	output.members = new(sync.Map)
 
 	connector.Outputs = append(connector.Outputs, *output)
 
@@ -114,15 +318,22 @@ func newInput(connector *Connector, inputName string) (input *Input, err error)
 	input.connector = connector
 
 	input.nameCStr = C.CString(inputName)
+	connector.handle.names = append(connector.handle.names, input.nameCStr)
 
 	input.native = C.RTIDDSConnector_getReader(unsafe.Pointer(connector.native), input.nameCStr)
+	if traceEnabled.Load() {
+		traceCall("RTIDDSConnector_getReader", strconv.Quote(inputName), nativeResult(input.native != nil))
+	}
 	if input.native == nil {
 		err = errors.New("Invalid Subscription::DataReader name")
+		connector.log(LogError, err.Error()+": "+inputName)
 		return nil, err
 	}
 	input.name = inputName
 	input.Samples = newSamples(input)
 	input.Infos = newInfos(input)
+	input.lastTaken = new(atomic.Int64)
+	input.recorder = new(atomic.Pointer[Recorder])
 
 	connector.Inputs = append(connector.Inputs, *input)
 
@@ -145,6 +356,83 @@ func newInfos(input *Input) (infos *Infos) {
 	return infos
 }
 
+// memberJSON builds a JSON document that only contains the given member.
+// Nested members are separated by dots (e.g. "pos.x"). Elements of arrays and
+// sequences (e.g. "seq[1]") cannot be set alone through JSON, which replaces
+// the whole array or sequence, so their names are rejected.
+func memberJSON(fieldName string, value json.RawMessage) (jsonData []byte, err error) {
+	if strings.IndexByte(fieldName, '[') >= 0 {
+		err = errors.New("Element paths are not supported for " + fieldName + ": set the whole array or sequence")
+		return nil, err
+	}
+	names := strings.Split(fieldName, ".")
+	member := value
+	for i := len(names) - 1; i >= 0; i-- {
+		member, err = json.Marshal(map[string]json.RawMessage{names[i]: member})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return member, nil
+}
+
+// elementName builds the field name of an element of an array or a sequence.
+// elemIndex is zero-based while the C layer expects one-based indexes.
+func elementName(fieldName string, elemIndex int) string {
+	return fmt.Sprintf("%s[%d]", fieldName, elemIndex+1)
+}
+
+// check returns an error if the connector is nil or deleted
+func (connector *Connector) check() error {
+	if connector == nil {
+		return errors.New("Connector is null")
+	}
+	if connector.native == nil {
+		return errors.New("Connector is deleted")
+	}
+	return nil
+}
+
+// check returns an error if the output is nil or its connector cannot be used
+func (output *Output) check() error {
+	if output == nil {
+		return errors.New("Output is null")
+	}
+	return output.connector.check()
+}
+
+// check returns an error if the instance is nil or its output cannot be used
+func (instance *Instance) check() error {
+	if instance == nil {
+		return errors.New("Instance is null")
+	}
+	return instance.output.check()
+}
+
+// check returns an error if the input is nil or its connector cannot be used
+func (input *Input) check() error {
+	if input == nil {
+		return errors.New("Input is null")
+	}
+	return input.connector.check()
+}
+
+// check returns an error if the samples are nil or their input cannot be used
+func (samples *Samples) check() error {
+	if samples == nil {
+		return errors.New("Samples is null")
+	}
+	return samples.input.check()
+}
+
+// check returns an error if the infos are nil or their input cannot be used
+func (infos *Infos) check() error {
+	if infos == nil {
+		return errors.New("Infos is null")
+	}
+	return infos.input.check()
+}
+
 /*******************
 * Public Functions *
 *******************/
@@ -156,48 +444,292 @@ func newInfos(input *Input) (infos *Infos) {
 //  String specification: str://"<dds><qos_library>…</qos_library></dds>"
 // If you omit the URL schema name, Connector will assume a file name. For example:
 //  File Specification: /usr/local/default_dds.xml
+//
+// The native resources are released by Delete. If a connector becomes
+// unreachable without being deleted, a finalizer deletes it, but calling
+// Delete explicitly is still strongly preferred because the garbage
+// collector gives no guarantee on when (or whether) finalizers run.
 func NewConnector(configName string, url string) (connector *Connector, err error) {
+	return NewConnectorWithOptions(configName, url, ConnectorOptions{})
+}
+
+// NewConnectorWithOptions is a constructor of Connector with options.
+// See NewConnector for the format of url and ConnectorOptions for the options.
+func NewConnectorWithOptions(configName string, url string, options ConnectorOptions) (connector *Connector, err error) {
 	connector = new(Connector)
+	connector.options = options
+	if options.EnableStats {
+		connector.stats = new(connectorStats)
+	}
 
 	configNameCStr := C.CString(configName)
 	defer C.free(unsafe.Pointer(configNameCStr))
-	urlCStr := C.CString(url)
+	nativeURL := url
+	if options.ExpandEnv {
+		nativeURL, err = expandEnv(url)
+		if err != nil {
+			connector.log(LogError, err.Error())
+			return nil, err
+		}
+		// Introspection reads the configuration with the variables expanded
+		connector.configs, _ = loadConfig(nativeURL)
+	}
+	if len(options.QoSOverrides) > 0 || len(options.Partitions) > 0 {
+		nativeURL, err = overrideQoS(nativeURL, configName, options.QoSOverrides, options.Partitions)
+		if err != nil {
+			connector.log(LogError, err.Error())
+			return nil, err
+		}
+	}
+	urlCStr := C.CString(nativeURL)
 	defer C.free(unsafe.Pointer(urlCStr))
 
-	connector.native = C.RTIDDSConnector_new(configNameCStr, urlCStr, nil)
+	connector.handle = &nativeHandle{configName: configName}
+	if options.DisableOnDataEvent {
+		// Allocated in C because the C layer may keep a pointer to it
+		connector.handle.config = (*C.struct_RTIDDSConnectorConfiguration)(C.calloc(1, C.sizeof_struct_RTIDDSConnectorConfiguration))
+		connector.handle.config.onDataEventEnabled = 0
+	}
+
+	connector.native = C.RTIDDSConnector_new(configNameCStr, urlCStr, connector.handle.config)
+	if traceEnabled.Load() {
+		traceCall("RTIDDSConnector_new", strconv.Quote(configName)+", "+strconv.Quote(nativeURL), nativeResult(connector.native != nil))
+	}
 	if connector.native == nil {
+		connector.handle.release()
 		err = errors.New("Invalid participant profile, xml path or xml profile")
+		connector.log(LogError, err.Error()+": "+configName+" in "+url)
 		return nil, err
 	}
+	connector.configName = configName
+	connector.url = url
+	connector.done = make(chan struct{})
+	connector.handle.native = connector.native
+	runtime.SetFinalizer(connector.handle, (*nativeHandle).release)
 
 	return connector, nil
 }
 
-// Delete is a destructor of Connector
+// Clone is a function to create a new connector, with its own participant,
+// from the configuration name, URL and options the connector was created with
+func (connector *Connector) Clone() (clone *Connector, err error) {
+	err = connector.check()
+	if err != nil {
+		return nil, err
+	}
+	return NewConnectorWithOptions(connector.configName, connector.url, connector.options)
+}
+
+// ConfigName is a function to get the name of the configuration
+// ("ParticipantLibrary::Participant") the connector was created with,
+// which is also returned by ParticipantName
+func (connector *Connector) ConfigName() string {
+	return connector.ParticipantName()
+}
+
+// ConfigURL is a function to get the URL of the XML documents the connector
+// was created with, e.g. the inline document of NewConnectorFromXML
+func (connector *Connector) ConfigURL() string {
+	if connector == nil {
+		return ""
+	}
+	return connector.url
+}
+
+// NewConnectorFromXML is a constructor of Connector from an XML document,
+// which may span multiple lines, instead of a URL. The document is checked
+// in Go first so that errors are reported with a description.
+func NewConnectorFromXML(configName string, xmlDoc string) (connector *Connector, err error) {
+	err = checkXML(xmlDoc)
+	if err != nil {
+		return nil, err
+	}
+	return NewConnector(configName, inlineURL(xmlDoc))
+}
+
+// NewConnectorFromBytes is a constructor of Connector from an XML document,
+// e.g. a configuration embedded with go:embed. See NewConnectorFromXML.
+func NewConnectorFromBytes(configName string, xmlDoc []byte) (connector *Connector, err error) {
+	return NewConnectorFromXML(configName, string(xmlDoc))
+}
+
+// NewConnectorFromReader is a constructor of Connector from an XML document
+// read from r until EOF. See NewConnectorFromXML.
+func NewConnectorFromReader(configName string, r io.Reader) (connector *Connector, err error) {
+	if r == nil {
+		err = errors.New("Reader is null")
+		return nil, err
+	}
+	xmlDoc, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewConnectorFromXML(configName, string(xmlDoc))
+}
+
+// NewConnectorWithRetry is a constructor of Connector that calls NewConnector
+// up to attempts times until it succeeds, e.g. when the XML file is not ready yet.
+// It waits backoff after the first failure and doubles the wait after each
+// following one. It returns the error of the last attempt.
+func NewConnectorWithRetry(configName string, url string, attempts int, backoff time.Duration) (connector *Connector, err error) {
+	return NewConnectorWithRetryContext(context.Background(), configName, url, attempts, backoff)
+}
+
+// NewConnectorWithRetryContext is NewConnectorWithRetry with a context.
+// When the context is done, it stops retrying and returns the error of the context.
+func NewConnectorWithRetryContext(ctx context.Context, configName string, url string, attempts int, backoff time.Duration) (connector *Connector, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for attempt := 1; ; attempt++ {
+		connector, err = NewConnector(configName, url)
+		if err == nil || attempt >= attempts {
+			return connector, err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// Delete is a destructor of Connector. Deleting a connector twice is a no-op.
 func (connector *Connector) Delete() (err error) {
 	if connector == nil {
 		err = errors.New("Connector is null")
 		return err
 	}
+	if connector.native == nil {
+		return nil
+	}
+	runtime.SetFinalizer(connector.handle, nil)
+
+	// Stop streaming goroutines before the native connector goes away
+	if connector.done != nil {
+		select {
+		case <-connector.done:
+		default:
+			close(connector.done)
+		}
+	}
+	connector.streams.Wait()
 
 	// Delete memory allocated in C layer
-	for _, input := range connector.Inputs {
-		C.free(unsafe.Pointer(input.nameCStr))
+	connector.Inputs = nil
+	connector.Outputs = nil
+	connector.handle.release()
+	connector.native = nil
+
+	return nil
+}
+
+// release deletes the native connector and frees the names of the inputs and
+// outputs. It is the finalizer of the handle.
+func (handle *nativeHandle) release() {
+	handle.deleteNative()
+	for _, name := range handle.names {
+		C.free(unsafe.Pointer(name))
 	}
-	for _, output := range connector.Outputs {
-		C.free(unsafe.Pointer(output.nameCStr))
+	handle.names = nil
+}
+
+// deleteNative deletes the native connector and its C configuration
+func (handle *nativeHandle) deleteNative() {
+	if handle.native != nil {
+		C.RTIDDSConnector_delete(handle.native)
+		if traceEnabled.Load() {
+			traceCall("RTIDDSConnector_delete", strconv.Quote(handle.configName), "")
+		}
+		handle.native = nil
 	}
+	C.free(unsafe.Pointer(handle.config))
+	handle.config = nil
+}
 
-	C.RTIDDSConnector_delete(connector.native)
-	connector.native = nil
+// Reconnect is a function to recover from the loss of the participant by
+// recreating the native connector from the configuration name, URL and options
+// the connector was created with. The inputs and outputs obtained before
+// are recreated by name in the new participant, so the existing *Input and
+// *Output keep working; samples not taken yet and the values set in the
+// instances of the outputs are lost. Streams, dispatchers and deadline watches
+// are stopped, as by Delete, and must be started again. If the new participant
+// cannot be created, the connector is left unchanged and an error is returned.
+// Reconnect is not synchronized: it must not be called concurrently with any
+// other use of the connector or of its inputs and outputs.
+func (connector *Connector) Reconnect() (err error) {
+	err = connector.check()
+	if err != nil {
+		return err
+	}
+
+	fresh, err := NewConnectorWithOptions(connector.configName, connector.url, connector.options)
+	if err != nil {
+		return err
+	}
+	// The new native connector is moved into this connector below
+	runtime.SetFinalizer(fresh.handle, nil)
+	readers := make([]unsafe.Pointer, len(connector.Inputs))
+	for i, input := range connector.Inputs {
+		readers[i] = C.RTIDDSConnector_getReader(unsafe.Pointer(fresh.native), input.nameCStr)
+		if traceEnabled.Load() {
+			traceCall("RTIDDSConnector_getReader", strconv.Quote(input.name), nativeResult(readers[i] != nil))
+		}
+		if readers[i] == nil {
+			fresh.Delete()
+			err = errors.New("Invalid Subscription::DataReader name")
+			connector.log(LogError, err.Error()+": "+input.name)
+			return err
+		}
+	}
+	writers := make([]unsafe.Pointer, len(connector.Outputs))
+	for i, output := range connector.Outputs {
+		writers[i] = C.RTIDDSConnector_getWriter(unsafe.Pointer(fresh.native), output.nameCStr)
+		if traceEnabled.Load() {
+			traceCall("RTIDDSConnector_getWriter", strconv.Quote(output.name), nativeResult(writers[i] != nil))
+		}
+		if writers[i] == nil {
+			fresh.Delete()
+			err = errors.New("Invalid Publication::DataWriter name")
+			connector.log(LogError, err.Error()+": "+output.name)
+			return err
+		}
+	}
+
+	// Stop the goroutines using the old native connector before deleting it
+	close(connector.done)
+	connector.streams.Wait()
+	connector.handle.deleteNative()
 
+	// Update the *Input and *Output returned by GetInput and GetOutput, which
+	// Samples.input and Instance.output point to, as well as their copies
+	for i := range connector.Inputs {
+		connector.Inputs[i].native = readers[i]
+		connector.Inputs[i].Samples.input.native = readers[i]
+	}
+	for i := range connector.Outputs {
+		connector.Outputs[i].native = writers[i]
+		connector.Outputs[i].Instance.output.native = writers[i]
+	}
+	connector.native = fresh.native
+	connector.handle.native = fresh.handle.native
+	connector.handle.config = fresh.handle.config
+	connector.done = fresh.done
+	fresh.native = nil
+	fresh.handle.native = nil
+	fresh.handle.config = nil
 	return nil
 }
 
 // GetOutput returns an output object
 func (connector *Connector) GetOutput(outputName string) (output *Output, err error) {
-	if connector == nil {
-		err = errors.New("Connector is null")
+	err = connector.check()
+	if err != nil {
 		return nil, err
 	}
 
@@ -210,8 +742,8 @@ func (connector *Connector) GetOutput(outputName string) (output *Output, err er
 
 // GetInput returns an input object
 func (connector *Connector) GetInput(inputName string) (input *Input, err error) {
-	if connector == nil {
-		err = errors.New("Connector is null")
+	err = connector.check()
+	if err != nil {
 		return nil, err
 	}
 
@@ -222,122 +754,465 @@ func (connector *Connector) GetInput(inputName string) (input *Input, err error)
 	return input, nil
 }
 
-// Wait is a function to block until data is available on an input
+// Wait is a function to block until data is available on an input.
+// timeoutMs is in milliseconds: -1 waits forever and DefaultTimeout waits
+// for the default timeout of the connector.
 func (connector *Connector) Wait(timeoutMs int) (err error) {
-	if connector == nil {
-		err = errors.New("Connector is null")
+	err = connector.check()
+	if err != nil {
 		return err
 	}
+	timeoutMs = connector.resolveTimeout(timeoutMs)
 
 	retcode := int(C.RTIDDSConnector_wait(unsafe.Pointer(connector.native), (C.int)(timeoutMs)))
-	if retcode == 10 /* DDS_RETCODE_TIMEOUT */ {
-		err = errors.New("Timeout")
-		return err
-	} else if retcode != 0 /* DDS_RETCODE_OK */ {
-		err = errors.New("RTIDDSConnector_wait error")
-		return err
+	if traceEnabled.Load() {
+		traceCall("RTIDDSConnector_wait", strconv.Itoa(timeoutMs), strconv.Itoa(retcode))
 	}
-	return nil
+	err = checkRetcode(retcode, "RTIDDSConnector_wait error")
+	if _, ok := err.(*DDSError); ok {
+		connector.log(LogError, err.Error())
+	}
+	return err
 }
 
-// Write is a function to write a DDS data instance in an output
-func (output *Output) Write() error {
-	// The C function does not return errors. In the future, we will check errors when supported in the C layer
-	// CON-24 (for more information)
-	C.RTIDDSConnector_write(unsafe.Pointer(output.connector.native), output.nameCStr, nil)
+// SetDefaultWaitTimeout is a function to set the timeout used when
+// DefaultTimeout is passed to Wait, WaitAny or TakeTimeout (see
+// ConnectorOptions.DefaultWaitTimeout). Zero or negative waits forever.
+func (connector *Connector) SetDefaultWaitTimeout(timeout time.Duration) error {
+	err := connector.check()
+	if err != nil {
+		return err
+	}
+	connector.options.DefaultWaitTimeout = timeout
 	return nil
 }
 
-// ClearMembers is a function to initialize a DDS data instance in an output
-func (output *Output) ClearMembers() error {
-	// The C function does not return errors. In the future, we will check errors when supported in the C layer
-	C.RTIDDSConnector_clear(unsafe.Pointer(output.connector.native), output.nameCStr)
-	return nil
+// resolveTimeout returns the timeout in milliseconds to pass to the C layer,
+// replacing DefaultTimeout with the default timeout of the connector
+func (connector *Connector) resolveTimeout(timeoutMs int) int {
+	if timeoutMs != DefaultTimeout {
+		return timeoutMs
+	}
+	timeout := connector.options.DefaultWaitTimeout
+	if timeout <= 0 {
+		return -1
+	}
+	// Round up so that sub-millisecond timeouts still wait
+	return int((timeout + time.Millisecond - 1) / time.Millisecond)
 }
 
-// SetUint8 is a function to set a value of type uint8 into samples
-func (instance *Instance) SetUint8(fieldName string, value uint8) error {
-	fieldNameCStr := C.CString(fieldName)
-	defer C.free(unsafe.Pointer(fieldNameCStr))
-
-	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
-	return nil
-}
+// WaitAny is a function to block until data is available on an input and
+// return the inputs with data available, in the order they were created
+// with GetInput. It is built on Wait and GetUnreadCount, so the Samples and
+// Infos of the returned inputs hold the samples read, which are also still
+// in the queue for a later Take. The samples of the other inputs are reset.
+func (connector *Connector) WaitAny(timeoutMs int) (inputs []*Input, err error) {
+	err = connector.Wait(timeoutMs)
+	if err != nil {
+		return nil, err
+	}
 
-// SetUint16 is a function to set a value of type uint16 into samples
-func (instance *Instance) SetUint16(fieldName string, value uint16) error {
-	fieldNameCStr := C.CString(fieldName)
-	defer C.free(unsafe.Pointer(fieldNameCStr))
+	inputs = []*Input{}
+	seen := map[string]bool{}
+	for i := range connector.Inputs {
+		// Samples.input is the *Input returned by GetInput
+		input := connector.Inputs[i].Samples.input
+		if seen[input.name] {
+			continue
+		}
+		seen[input.name] = true
 
-	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
-	return nil
+		count, err := input.GetUnreadCount()
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			inputs = append(inputs, input)
+		}
+	}
+	return inputs, nil
 }
 
-// SetUint32 is a function to set a value of type uint32 into samples
-func (instance *Instance) SetUint32(fieldName string, value uint32) error {
-	fieldNameCStr := C.CString(fieldName)
-	defer C.free(unsafe.Pointer(fieldNameCStr))
+// WaitWithContext is a function to block until data is available on an input
+// or the context is done. The native wait is polled in short slices so that
+// a cancellation is noticed promptly. It returns ctx.Err() when the context is
+// cancelled and ErrTimeout when the deadline of the context is reached.
+// A nil context or a context that is never done waits forever like Wait(-1).
+func (connector *Connector) WaitWithContext(ctx context.Context) (err error) {
+	err = connector.check()
+	if err != nil {
+		return err
+	}
+	if ctx == nil || ctx.Done() == nil {
+		return connector.Wait(-1)
+	}
 
-	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
-	return nil
-}
+	for {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return ErrTimeout
+			}
+			return ctx.Err()
+		default:
+		}
 
-// SetUint64 is a function to set a value of type uint64 into samples
-func (instance *Instance) SetUint64(fieldName string, value uint64) error {
-	fieldNameCStr := C.CString(fieldName)
-	defer C.free(unsafe.Pointer(fieldNameCStr))
+		timeoutMs := waitSliceMs
+		if deadline, ok := ctx.Deadline(); ok {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return ErrTimeout
+			}
+			// Round up so that the last slice does not spin on a zero timeout
+			remainingMs := int((remaining + time.Millisecond - 1) / time.Millisecond)
+			if remainingMs < timeoutMs {
+				timeoutMs = remainingMs
+			}
+		}
 
-	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
-	return nil
+		err = connector.Wait(timeoutMs)
+		if err != ErrTimeout {
+			return err
+		}
+	}
 }
 
-// SetInt8 is a function to set a value of type int8 into samples
-func (instance *Instance) SetInt8(fieldName string, value int8) error {
-	fieldNameCStr := C.CString(fieldName)
-	defer C.free(unsafe.Pointer(fieldNameCStr))
-
-	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
-	return nil
+// Name is a function to get the name of the output ("Publisher::DataWriter")
+func (output *Output) Name() string {
+	if output == nil {
+		return ""
+	}
+	return output.name
 }
 
-// SetInt16 is a function to set a value of type int16 into samples
-func (instance *Instance) SetInt16(fieldName string, value int16) error {
-	fieldNameCStr := C.CString(fieldName)
-	defer C.free(unsafe.Pointer(fieldNameCStr))
+// Name is a function to get the name of the input ("Subscriber::DataReader")
+func (input *Input) Name() string {
+	if input == nil {
+		return ""
+	}
+	return input.name
+}
 
-	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
-	return nil
+// Partitions is a function to get the partitions of the publisher of the output
+// set with the Partitions option of the connector, nil if none were set
+func (output *Output) Partitions() []string {
+	if output.check() != nil {
+		return nil
+	}
+	publisher, _, _ := strings.Cut(output.name, "::")
+	return output.connector.options.Partitions[publisher]
 }
 
-// SetInt32 is a function to set a value of type int32 into samples
-func (instance *Instance) SetInt32(fieldName string, value int32) error {
-	fieldNameCStr := C.CString(fieldName)
-	defer C.free(unsafe.Pointer(fieldNameCStr))
+// Write is a function to write a DDS data instance in an output.
+// The instance is not cleared after a write: the members that are not set
+// again keep their values and are written again. Use ClearMembers to reset
+// them to their default values, or WriteKeyed to write a sparse update.
+func (output *Output) Write() error {
+	err := output.check()
+	if err != nil {
+		output.recordWrite(0, err)
+		return err
+	}
 
-	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
+	// The C function does not return errors. In the future, we will check errors when supported in the C layer
+	// CON-24 (for more information)
+	C.RTIDDSConnector_write(unsafe.Pointer(output.connector.native), output.nameCStr, nil)
+	if traceEnabled.Load() {
+		traceCall("RTIDDSConnector_write", strconv.Quote(output.name)+", null", "")
+	}
+	output.recordWrite(1, nil)
 	return nil
 }
 
-// SetInt64 is a function to set a value of type int64 into samples
-func (instance *Instance) SetInt64(fieldName string, value int64) error {
-	fieldNameCStr := C.CString(fieldName)
-	defer C.free(unsafe.Pointer(fieldNameCStr))
+// WriteWithParams is a function to write a DDS data instance in an output
+// with parameters in JSON, which are passed as is to the C layer. For example:
+//  {"action":"dispose", "source_timestamp":1500000000000000000}
+// See WriteParams to build the parameters from Go.
+func (output *Output) WriteWithParams(jsonParams string) error {
+	err := output.check()
+	if err != nil {
+		output.recordWrite(0, err)
+		return err
+	}
 
-	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
+	jsonParamsCStr := C.CString(jsonParams)
+	defer C.free(unsafe.Pointer(jsonParamsCStr))
+
+	// The C function does not return errors. In the future, we will check errors when supported in the C layer
+	C.RTIDDSConnector_write(unsafe.Pointer(output.connector.native), output.nameCStr, jsonParamsCStr)
+	if traceEnabled.Load() {
+		traceCall("RTIDDSConnector_write", strconv.Quote(output.name)+", "+jsonParams, "")
+	}
+	output.recordWrite(1, nil)
 	return nil
 }
 
-// SetUint is a function to set a value of type uint into samples
-func (instance *Instance) SetUint(fieldName string, value uint) error {
-	fieldNameCStr := C.CString(fieldName)
-	defer C.free(unsafe.Pointer(fieldNameCStr))
+// ClearMembers is a function to initialize a DDS data instance in an output.
+// Every member of the instance is reset to its default value: zero for the
+// numbers and enums (or the default declared in the XML type), false, empty
+// strings and sequences, and no value for the optional members. It is the
+// same as Instance.Clear.
+func (output *Output) ClearMembers() error {
+	err := output.check()
+	if err != nil {
+		return err
+	}
 
-	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
+	// The C function does not return errors. In the future, we will check errors when supported in the C layer
+	C.RTIDDSConnector_clear(unsafe.Pointer(output.connector.native), output.nameCStr)
+	if traceEnabled.Load() {
+		traceCall("RTIDDSConnector_clear", strconv.Quote(output.name), "")
+	}
 	return nil
 }
 
-// SetInt is a function to set a value of type int into samples
-func (instance *Instance) SetInt(fieldName string, value int) error {
+// WriteKeyed is a function to write an update of the instance identified by
+// key, which holds the value of every key member declared in the XML type.
+// Only the members in fields are set: as with Write, the other members keep
+// the values of the previous write of the output. Call ClearMembers first to
+// write them with their default values instead.
+func (output *Output) WriteKeyed(key map[string]interface{}, fields map[string]interface{}) (err error) {
+	err = output.check()
+	if err != nil {
+		output.recordWrite(0, err)
+		return err
+	}
+
+	configs, err := output.connector.config()
+	if err != nil {
+		return err
+	}
+	typ, err := output.connector.entityType(output.name)
+	if err != nil {
+		return err
+	}
+	members, err := allMembers(configs, typ)
+	if err != nil {
+		return err
+	}
+
+	sample := make(map[string]interface{}, len(key)+len(fields))
+	for _, member := range members {
+		if !member.Key {
+			continue
+		}
+		value, ok := key[member.Name]
+		if !ok {
+			err = errors.New("Missing key member: " + member.Name)
+			return err
+		}
+		sample[member.Name] = value
+	}
+	if len(sample) != len(key) {
+		for name := range key {
+			if _, ok := sample[name]; !ok {
+				err = errors.New("Not a key member: " + name)
+				return err
+			}
+		}
+	}
+	for name, value := range fields {
+		if _, ok := sample[name]; ok {
+			err = errors.New("Key member in fields: " + name)
+			return err
+		}
+		sample[name] = value
+	}
+
+	err = output.Instance.Set(sample)
+	if err != nil {
+		return err
+	}
+	return output.Write()
+}
+
+// WriteBatch is a function to write several DDS data instances in an output.
+// All the samples are marshalled to JSON, and checked with Instance.ValidateJSON
+// when the ValidateJSON option is set, before anything is written, so a sample
+// that cannot be marshalled or is not valid leaves the batch unwritten. Each sample
+// then costs two calls to the C layer (set and write), as opposed to one call
+// per member with the SetXXX functions. As with Instance.Set, the instance is
+// not cleared between samples. Coalescing into fewer DDS messages is
+// controlled by the batch QoS of the DataWriter in the XML configuration.
+func (output *Output) WriteBatch(samples []interface{}) (err error) {
+	err = output.check()
+	if err != nil {
+		output.recordWrite(0, err)
+		return err
+	}
+
+	jsonCStrs := make([]*C.char, 0, len(samples))
+	defer func() {
+		for _, jsonCStr := range jsonCStrs {
+			C.free(unsafe.Pointer(jsonCStr))
+		}
+	}()
+	codec := getCodec()
+	for _, sample := range samples {
+		jsonData, err := codec.Marshal(sample)
+		if err != nil {
+			output.recordWrite(0, err)
+			return err
+		}
+		if output.connector.options.ValidateJSON {
+			err = output.Instance.ValidateJSON(jsonData)
+			if err != nil {
+				output.recordWrite(0, err)
+				return err
+			}
+		}
+		jsonCStrs = append(jsonCStrs, C.CString(string(jsonData)))
+	}
+
+	for _, jsonCStr := range jsonCStrs {
+		C.RTIDDSConnector_setJSONInstance(unsafe.Pointer(output.connector.native), output.nameCStr, jsonCStr)
+		if traceEnabled.Load() {
+			traceCall("RTIDDSConnector_setJSONInstance", strconv.Quote(output.name)+", "+C.GoString(jsonCStr), "")
+		}
+		C.RTIDDSConnector_write(unsafe.Pointer(output.connector.native), output.nameCStr, nil)
+		if traceEnabled.Load() {
+			traceCall("RTIDDSConnector_write", strconv.Quote(output.name)+", null", "")
+		}
+	}
+	output.recordWrite(len(jsonCStrs), nil)
+	return nil
+}
+
+// SetUint8 is a function to set a value of type uint8 into samples
+func (instance *Instance) SetUint8(fieldName string, value uint8) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	fieldNameCStr := C.CString(fieldName)
+	defer C.free(unsafe.Pointer(fieldNameCStr))
+
+	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
+	return nil
+}
+
+// SetUint16 is a function to set a value of type uint16 into samples
+func (instance *Instance) SetUint16(fieldName string, value uint16) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	fieldNameCStr := C.CString(fieldName)
+	defer C.free(unsafe.Pointer(fieldNameCStr))
+
+	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
+	return nil
+}
+
+// SetUint32 is a function to set a value of type uint32 into samples
+func (instance *Instance) SetUint32(fieldName string, value uint32) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	fieldNameCStr := C.CString(fieldName)
+	defer C.free(unsafe.Pointer(fieldNameCStr))
+
+	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
+	return nil
+}
+
+// SetUint64 is a function to set a value of type uint64 into samples.
+// The value is set through JSON so that values above 2^53 are not rounded.
+// The C layer only sets an element of an array or a sequence (e.g. "seq[1]")
+// as a double, so an error is returned for an element above 2^53.
+func (instance *Instance) SetUint64(fieldName string, value uint64) error {
+	if strings.IndexByte(fieldName, '[') >= 0 && value <= maxExactInteger {
+		return instance.SetFloat64(fieldName, float64(value))
+	}
+	jsonData, err := memberJSON(fieldName, json.RawMessage(strconv.FormatUint(value, 10)))
+	if err != nil {
+		return err
+	}
+	return instance.SetJSON(jsonData)
+}
+
+// SetInt8 is a function to set a value of type int8 into samples
+func (instance *Instance) SetInt8(fieldName string, value int8) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	fieldNameCStr := C.CString(fieldName)
+	defer C.free(unsafe.Pointer(fieldNameCStr))
+
+	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
+	return nil
+}
+
+// SetInt16 is a function to set a value of type int16 into samples
+func (instance *Instance) SetInt16(fieldName string, value int16) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	fieldNameCStr := C.CString(fieldName)
+	defer C.free(unsafe.Pointer(fieldNameCStr))
+
+	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
+	return nil
+}
+
+// SetInt32 is a function to set a value of type int32 into samples
+func (instance *Instance) SetInt32(fieldName string, value int32) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	fieldNameCStr := C.CString(fieldName)
+	defer C.free(unsafe.Pointer(fieldNameCStr))
+
+	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
+	return nil
+}
+
+// SetInt64 is a function to set a value of type int64 into samples.
+// The value is set through JSON so that values above 2^53 are not rounded.
+// The C layer only sets an element of an array or a sequence (e.g. "seq[1]")
+// as a double, so an error is returned for an element above 2^53 in magnitude.
+func (instance *Instance) SetInt64(fieldName string, value int64) error {
+	if strings.IndexByte(fieldName, '[') >= 0 && value <= maxExactInteger && value >= -maxExactInteger {
+		return instance.SetFloat64(fieldName, float64(value))
+	}
+	jsonData, err := memberJSON(fieldName, json.RawMessage(strconv.FormatInt(value, 10)))
+	if err != nil {
+		return err
+	}
+	return instance.SetJSON(jsonData)
+}
+
+// SetUint is a function to set a value of type uint into samples
+func (instance *Instance) SetUint(fieldName string, value uint) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	fieldNameCStr := C.CString(fieldName)
+	defer C.free(unsafe.Pointer(fieldNameCStr))
+
+	C.RTIDDSConnector_setNumberIntoSamples(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, fieldNameCStr, C.double(value))
+	return nil
+}
+
+// SetInt is a function to set a value of type int into samples
+func (instance *Instance) SetInt(fieldName string, value int) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
@@ -347,6 +1222,11 @@ func (instance *Instance) SetInt(fieldName string, value int) error {
 
 // SetFloat32 is a function to set a value of type float32 into samples
 func (instance *Instance) SetFloat32(fieldName string, value float32) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
@@ -356,6 +1236,11 @@ func (instance *Instance) SetFloat32(fieldName string, value float32) error {
 
 // SetFloat64 is a function to set a value of type float64 into samples
 func (instance *Instance) SetFloat64(fieldName string, value float64) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
@@ -363,8 +1248,25 @@ func (instance *Instance) SetFloat64(fieldName string, value float64) error {
 	return nil
 }
 
-// SetString is a function that set a string to a fieldname of the samples
+// SetString is a function that set a string to a fieldname of the samples.
+// The UTF-8 bytes of the value are passed as is. It returns an error when
+// the value contains a NUL character, which would end the C string, or is
+// longer than the stringMaxLength of the member in the XML type.
 func (instance *Instance) SetString(fieldName string, value string) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	// The C layer would truncate the string silently
+	if strings.IndexByte(value, 0) >= 0 {
+		err = errors.New("String contains a NUL character for " + fieldName)
+		return err
+	}
+	err = instance.output.checkString(fieldName, value)
+	if err != nil {
+		return err
+	}
 
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
@@ -379,6 +1281,11 @@ func (instance *Instance) SetString(fieldName string, value string) error {
 
 // SetByte is a function to set a byte to a fieldname of the samples
 func (instance *Instance) SetByte(fieldName string, value byte) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
@@ -388,6 +1295,11 @@ func (instance *Instance) SetByte(fieldName string, value byte) error {
 
 // SetRune is a function to set rune to a fieldname of the samples
 func (instance *Instance) SetRune(fieldName string, value rune) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
@@ -397,6 +1309,11 @@ func (instance *Instance) SetRune(fieldName string, value rune) error {
 
 // SetBoolean is a function to set boolean to a fieldname of the samples
 func (instance *Instance) SetBoolean(fieldName string, value bool) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
@@ -412,18 +1329,242 @@ func (instance *Instance) SetBoolean(fieldName string, value bool) error {
 
 // SetJSON is a function to set JSON string in the form of slice of bytes into Instance
 func (instance *Instance) SetJSON(json []byte) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+	if instance.output.connector.options.ValidateJSON {
+		err = instance.ValidateJSON(json)
+		if err != nil {
+			return err
+		}
+	}
+
 	jsonCStr := C.CString(string(json))
 	defer C.free(unsafe.Pointer(jsonCStr))
 
 	C.RTIDDSConnector_setJSONInstance(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, jsonCStr)
+	if traceEnabled.Load() {
+		traceCall("RTIDDSConnector_setJSONInstance", strconv.Quote(instance.output.name)+", "+string(json), "")
+	}
+	return nil
+}
+
+// jsonBuffer is a buffer allocated in C, reused to pass JSON samples to the
+// C layer without allocating a C string for each of them
+type jsonBuffer struct {
+	data unsafe.Pointer
+	size int
+}
+
+// free releases the memory of the buffer
+func (buffer *jsonBuffer) free() {
+	C.free(buffer.data)
+	buffer.data = nil
+	buffer.size = 0
+}
+
+// setJSONBuffer is SetJSON copying the JSON into buffer, which is grown if needed
+func (instance *Instance) setJSONBuffer(buffer *jsonBuffer, json []byte) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+	if instance.output.connector.options.ValidateJSON {
+		err = instance.ValidateJSON(json)
+		if err != nil {
+			return err
+		}
+	}
+
+	if buffer.size < len(json)+1 {
+		buffer.free()
+		buffer.size = 2 * (len(json) + 1)
+		buffer.data = C.malloc(C.size_t(buffer.size))
+	}
+	data := unsafe.Slice((*byte)(buffer.data), buffer.size)
+	copy(data, json)
+	data[len(json)] = 0
+
+	C.RTIDDSConnector_setJSONInstance(unsafe.Pointer(instance.output.connector.native), instance.output.nameCStr, (*C.char)(buffer.data))
+	if traceEnabled.Load() {
+		traceCall("RTIDDSConnector_setJSONInstance", strconv.Quote(instance.output.name)+", "+string(json), "")
+	}
 	return nil
 }
 
+// SetInt32Array is a function to set a slice of int32 into an array or a sequence of the samples
+func (instance *Instance) SetInt32Array(fieldName string, values []int32) error {
+	return setArray(instance, fieldName, values)
+}
+
+// SetFloat64Array is a function to set a slice of float64 into an array or a sequence of the samples
+func (instance *Instance) SetFloat64Array(fieldName string, values []float64) error {
+	return setArray(instance, fieldName, values)
+}
+
+// SetStringArray is a function to set a slice of strings into an array or a sequence of the samples
+func (instance *Instance) SetStringArray(fieldName string, values []string) error {
+	return setArray(instance, fieldName, values)
+}
+
+// setArray sets a whole array or sequence member in one call, after checking
+// that the values fit the bound declared in the XML configuration
+func setArray[T any](instance *Instance, fieldName string, values []T) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	member, err := instance.output.member(fieldName)
+	if err != nil {
+		return err
+	}
+	bound, err := member.bound(fieldName)
+	if err != nil {
+		return err
+	}
+	if bound >= 0 && len(values) > bound {
+		err = errors.New("Too many elements for " + fieldName + ": " + strconv.Itoa(len(values)) + ", the bound is " + strconv.Itoa(bound))
+		return err
+	}
+
+	if values == nil {
+		values = []T{}
+	}
+	value, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	jsonData, err := memberJSON(fieldName, value)
+	if err != nil {
+		return err
+	}
+	return instance.SetJSON(jsonData)
+}
+
+// SetMap is a function to set a map member of the samples. The C layer
+// represents maps in JSON as objects, so the keys are strings: the keys of
+// maps with integer keys are the decimal representation of the integers.
+// The values may be of any type that encoding/json marshals to the JSON
+// of the value type of the map (numbers, booleans, strings, nested maps or
+// structs as map[string]interface{}). Maps require a version of the C library
+// that supports map types.
+func (instance *Instance) SetMap(fieldName string, m map[string]interface{}) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	value, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	jsonData, err := memberJSON(fieldName, value)
+	if err != nil {
+		return err
+	}
+	return instance.SetJSON(jsonData)
+}
+
+// SetWString is a function to set a value of type wstring into samples.
+// SetString passes strings to the C layer as UTF-8 char strings, whereas
+// SetWString sets the value through JSON, which the C layer converts to wide
+// characters, so any Unicode text is preserved. DDS wide characters are 16-bit:
+// characters outside the Basic Multilingual Plane (e.g. emoji) may not be
+// supported by the C layer.
+func (instance *Instance) SetWString(fieldName string, value string) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	if strings.IndexByte(value, 0) >= 0 {
+		err = errors.New("String contains a NUL character for " + fieldName)
+		return err
+	}
+	err = instance.output.checkString(fieldName, value)
+	if err != nil {
+		return err
+	}
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	jsonData, err := memberJSON(fieldName, jsonValue)
+	if err != nil {
+		return err
+	}
+	return instance.SetJSON(jsonData)
+}
+
+// SetTime is a function to set a time into a member of the samples made of
+// the sec and nanosec members, such as DDS Time_t and Duration_t
+func (instance *Instance) SetTime(fieldName string, t time.Time) error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(timeMember{Sec: t.Unix(), Nanosec: uint32(t.Nanosecond())})
+	if err != nil {
+		return err
+	}
+	jsonData, err := memberJSON(fieldName, value)
+	if err != nil {
+		return err
+	}
+	return instance.SetJSON(jsonData)
+}
+
+// SetBytes is a function to set a slice of bytes into an octet sequence or array of the samples
+func (instance *Instance) SetBytes(fieldName string, value []byte) error {
+	// The C layer represents octet sequences in JSON as arrays of numbers
+	var buffer strings.Builder
+	buffer.WriteByte('[')
+	for i, b := range value {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		buffer.WriteString(strconv.Itoa(int(b)))
+	}
+	buffer.WriteByte(']')
+
+	jsonData, err := memberJSON(fieldName, json.RawMessage(buffer.String()))
+	if err != nil {
+		return err
+	}
+	return instance.SetJSON(jsonData)
+}
+
+// Clear is a function to reset all the members of the instance to their
+// default values (see Output.ClearMembers), e.g. before building a new sample
+func (instance *Instance) Clear() error {
+	err := instance.check()
+	if err != nil {
+		return err
+	}
+	return instance.output.ClearMembers()
+}
+
+// ClearField is a function to unset an optional member of the samples,
+// so that it is written without a value
+func (instance *Instance) ClearField(fieldName string) error {
+	jsonData, err := memberJSON(fieldName, json.RawMessage("null"))
+	if err != nil {
+		return err
+	}
+	return instance.SetJSON(jsonData)
+}
+
 // Set is a function that consumes an interface
 // of multiple samples with different types and value
 // TODO - think about a new name for this a function (e.g. SetType, SetFromType, FromType)
 func (instance *Instance) Set(v interface{}) (err error) {
-	jsonData, err := json.Marshal(v)
+	jsonData, err := getCodec().Marshal(v)
 	if err != nil {
 		return err
 	}
@@ -436,39 +1577,291 @@ func (instance *Instance) Set(v interface{}) (err error) {
 	return nil
 }
 
+// Partitions is a function to get the partitions of the subscriber of the input
+// set with the Partitions option of the connector, nil if none were set
+func (input *Input) Partitions() []string {
+	if input.check() != nil {
+		return nil
+	}
+	subscriber, _, _ := strings.Cut(input.name, "::")
+	return input.connector.options.Partitions[subscriber]
+}
+
 // Read is a function to read DDS samples from the DDS DataReader
 // and allow access them via the Connector Samples. The Read function
 // does not remove DDS samples from the DDS DataReader's receive queue.
+// It returns ErrNoData when there are no samples to read.
 func (input *Input) Read() (err error) {
-	if input == nil {
-		err = errors.New("Input is null")
+	err = input.check()
+	if err != nil {
+		input.recordTake(false, 0, err)
 		return err
 	}
 
+	input.Samples.indexes = nil
 	// The C function does not return errors. In the future, we will update this when supported in the C layer
 	C.RTIDDSConnector_read(unsafe.Pointer(input.connector.native), input.nameCStr)
+	if traceEnabled.Load() {
+		traceCall("RTIDDSConnector_read", strconv.Quote(input.name), "")
+	}
+	length := input.Samples.GetLength()
+	input.recordTake(false, length, nil)
+	if length == 0 {
+		return ErrNoData
+	}
 	return nil
 }
 
 // Take is a function to take DDS samples from the DDS DataReader
 // and allow access them via the Connector Samples. The Take
 // function removes DDS samples from the DDS DataReader's receive queue.
+// It returns ErrNoData when there are no samples to take.
 func (input *Input) Take() (err error) {
-	if input == nil {
-		err = errors.New("Input is null")
+	err = input.check()
+	if err != nil {
+		input.recordTake(true, 0, err)
 		return err
 	}
+	input.Samples.indexes = nil
 	// The C function does not return errors. In the future, we will update this when supported in the C layer
 	C.RTIDDSConnector_take(unsafe.Pointer(input.connector.native), input.nameCStr)
+	if traceEnabled.Load() {
+		traceCall("RTIDDSConnector_take", strconv.Quote(input.name), "")
+	}
+	length := input.Samples.GetLength()
+	input.recordTake(true, length, nil)
+	if length == 0 {
+		return ErrNoData
+	}
+	input.recordReception()
+	input.record()
 	return nil
 }
 
+// TakeCount is a function to take DDS samples from the DDS DataReader
+// and return the number of samples taken. It returns ErrNoData when
+// there are no samples to take.
+func (input *Input) TakeCount() (count int, err error) {
+	err = input.Take()
+	if err != nil {
+		return 0, err
+	}
+	return input.Samples.GetLength(), nil
+}
+
+// TakeTimeout is a function to wait up to timeoutMs milliseconds (forever if
+// -1, the default timeout of the connector if DefaultTimeout) for data on this
+// input, and then take it as Take does. It returns
+// ErrTimeout when no sample arrives in time. The C layer can only wait for data
+// on any input, so TakeTimeout waits and takes in turns, and pauses for up to
+// a few milliseconds when the data that woke it up belongs to another input.
+func (input *Input) TakeTimeout(timeoutMs int) (err error) {
+	err = input.check()
+	if err != nil {
+		return err
+	}
+	timeoutMs = input.connector.resolveTimeout(timeoutMs)
+
+	ctx := context.Background()
+	if timeoutMs >= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+	err = input.takeWithContext(ctx)
+	if err == context.DeadlineExceeded {
+		return ErrTimeout
+	}
+	return err
+}
+
+// takeWithContext takes the samples of the input, waiting for data until the
+// context is done. The C layer can only wait for data on any input, and it
+// keeps returning at once while another input has data that is not taken,
+// so the pause between two waits is doubled up to maxWaitPause instead of
+// spinning. It returns the error of the context when the context is done first.
+func (input *Input) takeWithContext(ctx context.Context) (err error) {
+	pause := time.Millisecond
+	err = input.Take()
+	for err == ErrNoData {
+		err = input.connector.WaitWithContext(ctx)
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		err = input.Take()
+		if err != ErrNoData {
+			break
+		}
+
+		// The data available belongs to another input
+		timer := time.NewTimer(pause)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if pause < maxWaitPause {
+			pause *= 2
+		}
+	}
+	return err
+}
+
+// TakeByInstance is a function to take DDS samples from the DDS DataReader
+// and group the indexes of the samples by instance. The keys of the map are
+// the JSON of the key members, as returned by Samples.GetKeyValue, and each
+// slice of indexes is in the order of the samples, valid or not.
+// It returns ErrNoData when there are no samples to take.
+func (input *Input) TakeByInstance() (instances map[string][]int, err error) {
+	err = input.Take()
+	if err != nil {
+		return nil, err
+	}
+
+	instances = map[string][]int{}
+	first := input.Samples.firstIndex()
+	for i := first; i < first+input.Samples.GetLength(); i++ {
+		jsonKey, err := input.Samples.GetKeyValue(i)
+		if err != nil {
+			return nil, err
+		}
+		instances[jsonKey] = append(instances[jsonKey], i)
+	}
+	return instances, nil
+}
+
+// NextContext is a function to receive one sample: it waits for data on
+// this input until the context is done, takes the samples and unmarshals
+// the first valid one into v. It returns the error of the context when the
+// context is done first, and ErrNoData when all the samples taken are invalid.
+//
+// The C layer takes all the samples at once, so when several valid samples
+// arrive together, the following ones are kept in the input and returned by
+// the next calls to NextContext, without waiting. These buffered samples are
+// not visible to Take, Read or Samples.
+func (input *Input) NextContext(ctx context.Context, v interface{}) (err error) {
+	err = input.check()
+	if err != nil {
+		return err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if len(input.pending) == 0 {
+		err = input.takeWithContext(ctx)
+		if err != nil {
+			return err
+		}
+		first := input.Samples.firstIndex()
+		for i := first; i < first+input.Samples.GetLength(); i++ {
+			if !input.Infos.IsValid(i) {
+				continue
+			}
+			jsonData, err := input.Samples.GetJSON(i)
+			if err != nil {
+				return err
+			}
+			input.pending = append(input.pending, jsonData)
+		}
+		if len(input.pending) == 0 {
+			return ErrNoData
+		}
+	}
+
+	jsonData := input.pending[0]
+	input.pending = input.pending[1:]
+	return getCodec().Unmarshal(jsonData, v)
+}
+
+// TakeN is a function to take DDS samples from the DDS DataReader and only
+// expose the first max of them through Samples and Infos. The C layer can only
+// take all the samples, so the samples beyond max are removed from the DDS
+// DataReader's receive queue as well and are lost. TakeN returns the number of
+// samples taken, which may be greater than max, while GetLength returns the
+// number of samples exposed. It returns ErrNoData when there are no samples.
+func (input *Input) TakeN(max int) (count int, err error) {
+	if max < 0 {
+		err = errors.New("Invalid max: " + strconv.Itoa(max))
+		return 0, err
+	}
+	count, err = input.TakeCount()
+	if err != nil {
+		return 0, err
+	}
+	if count > max {
+		input.Samples.indexes = make([]int, max)
+		for i := range input.Samples.indexes {
+			input.Samples.indexes[i] = i
+		}
+	}
+	return count, nil
+}
+
+// GetUnreadCount is a function to get the number of samples in the receive
+// queue of the DDS DataReader without removing them. The C layer has no
+// direct count, so it is implemented with Read followed by GetLength:
+//   - the samples stay in the queue and a later Take still returns them;
+//   - samples already returned by a previous Read are counted again, since
+//     Read does not filter on the sample state;
+//   - like Read, it replaces the Samples and Infos of the input with the
+//     samples in the queue.
+// It returns 0 and a nil error when the queue is empty.
+func (input *Input) GetUnreadCount() (count int, err error) {
+	err = input.Read()
+	if err == ErrNoData {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return input.Samples.GetLength(), nil
+}
+
+// TakeJSON is a function to take DDS samples from the DDS DataReader
+// and return the JSON of every valid sample. Invalid samples are skipped.
+func (input *Input) TakeJSON() (jsonSamples []string, err error) {
+	err = input.Take()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonSamples = []string{}
+	first := input.Samples.firstIndex()
+	for i := first; i < first+input.Samples.GetLength(); i++ {
+		if !input.Infos.IsValid(i) {
+			continue
+		}
+		jsonData, err := input.Samples.GetJSON(i)
+		if err != nil {
+			return nil, err
+		}
+		jsonSamples = append(jsonSamples, string(jsonData))
+	}
+	return jsonSamples, nil
+}
+
+// TakeInto is a function to take DDS samples from the DDS DataReader
+// and unmarshal every valid sample into the slice pointed to by v
+// (e.g. *[]MyType). Invalid samples are skipped.
+func (input *Input) TakeInto(v interface{}) (err error) {
+	jsonSamples, err := input.TakeJSON()
+	if err != nil {
+		return err
+	}
+	return getCodec().Unmarshal([]byte("["+strings.Join(jsonSamples, ",")+"]"), v)
+}
+
 // AsyncSubscribe is a function to subscribe DDS samples in an asynchronous way.
 // Internllay, it takes DDS samples from the DDS DataReader when they arrive.
 // Then, it invokes the callback function (cb SampleHandler) that will handle received samples.
 func (input *Input) AsyncSubscribe(cb SampleHandler) (err error) {
-	if input == nil {
-		err = errors.New("Input is null")
+	err = input.check()
+	if err != nil {
 		return err
 	}
 	//input.mu.Lock()
@@ -487,8 +1880,8 @@ func (input *Input) AsyncSubscribe(cb SampleHandler) (err error) {
 // Internally, it taks DDS samples from the DDS DataReader when they arrive.
 // Then, it sends arrived DDS samples to the channel (samples chan *Samples).
 func (input *Input) ChannelSubscribe(samples chan *Samples) (err error) {
-	if input == nil {
-		err = errors.New("Input is null")
+	err = input.check()
+	if err != nil {
 		return err
 	}
 	//input.mu.Lock()
@@ -505,162 +1898,714 @@ func (input *Input) ChannelSubscribe(samples chan *Samples) (err error) {
 
 // GetLength is a function to get the number of samples
 func (samples *Samples) GetLength() (length int) {
+	if samples.check() != nil {
+		return 0
+	}
+	if samples.indexes != nil {
+		return len(samples.indexes)
+	}
 	length = int(C.RTIDDSConnector_getSamplesLength(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr))
 	return length
 }
 
+// getNumber retrieves a number from the samples as a double
+func (samples *Samples) getNumber(index int, fieldName string) (value float64) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
+	fieldNameCStr := C.CString(fieldName)
+	defer C.free(unsafe.Pointer(fieldNameCStr))
+
+	value = float64(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
+	return value
+}
+
+// firstIndex returns the index of the first sample: 0, or 1 with
+// the OneBasedIndex option
+func (samples *Samples) firstIndex() int {
+	if samples.check() == nil && samples.input.connector.options.OneBasedIndex {
+		return 1
+	}
+	return 0
+}
+
+// nativeIndex converts the index of a sample into the one-based index
+// of the C layer, following the filter of the last filtered read or take.
+// All the accessors go through it: it returns an error for the nil samples
+// and for the indexes out of the range of the samples, which the C layer
+// does not check.
+func (samples *Samples) nativeIndex(index int) (nativeIndex C.int, err error) {
+	err = samples.check()
+	if err != nil {
+		return 0, err
+	}
+
+	first := samples.firstIndex()
+	length := samples.GetLength()
+	if index < first || index >= first+length {
+		err = errors.New("Index out of range: " + strconv.Itoa(index) + " (" + strconv.Itoa(length) + " samples from index " + strconv.Itoa(first) + ")")
+		return 0, err
+	}
+	index -= first
+	if samples.indexes != nil {
+		index = samples.indexes[index]
+	}
+	return C.int(index + 1), nil
+}
+
 // GetUint8 is a function to retrieve a value of type uint8 from the samples
 func (samples *Samples) GetUint8(index int, fieldName string) (value uint8) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = uint8(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = uint8(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
 // GetUint16 is a function to retrieve a value of type uint16 from the samples
 func (samples *Samples) GetUint16(index int, fieldName string) (value uint16) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = uint16(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = uint16(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
 // GetUint32 is a function to retrieve a value of type uint32 from the samples
 func (samples *Samples) GetUint32(index int, fieldName string) (value uint32) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = uint32(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = uint32(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
-// GetUint64 is a function to retrieve a value of type uint64 from the samples
+// GetUint64 is a function to retrieve a value of type uint64 from the samples.
+// The value is parsed from the JSON sample so that it is not rounded to a double.
+// When it cannot be parsed, 0 is returned and the error is logged.
 func (samples *Samples) GetUint64(index int, fieldName string) (value uint64) {
-	fieldNameCStr := C.CString(fieldName)
-	defer C.free(unsafe.Pointer(fieldNameCStr))
-
-	value = uint64(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	member, err := samples.getJSONMember(index, fieldName)
+	if err == nil {
+		value, err = strconv.ParseUint(string(member), 10, 64)
+	}
+	if err != nil {
+		samples.logError("Cannot get " + fieldName + " as a uint64: " + err.Error())
+		return 0
+	}
 	return value
 }
 
 // GetInt8 is a function to retrieve a value of type int8 from the samples
 func (samples *Samples) GetInt8(index int, fieldName string) (value int8) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = int8(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = int8(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
 // GetInt16 is a function to retrieve a value of type int16 from the samples
 func (samples *Samples) GetInt16(index int, fieldName string) (value int16) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = int16(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = int16(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
 // GetInt32 is a function to retrieve a value of type int32 from the samples
 func (samples *Samples) GetInt32(index int, fieldName string) (value int32) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = int32(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = int32(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
-// GetInt64 is a function to retrieve a value of type int64 from the samples
+// GetInt64 is a function to retrieve a value of type int64 from the samples.
+// The value is parsed from the JSON sample so that it is not rounded to a double.
+// When it cannot be parsed, 0 is returned and the error is logged.
 func (samples *Samples) GetInt64(index int, fieldName string) (value int64) {
-	fieldNameCStr := C.CString(fieldName)
-	defer C.free(unsafe.Pointer(fieldNameCStr))
-
-	value = int64(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	member, err := samples.getJSONMember(index, fieldName)
+	if err == nil {
+		value, err = strconv.ParseInt(string(member), 10, 64)
+	}
+	if err != nil {
+		samples.logError("Cannot get " + fieldName + " as an int64: " + err.Error())
+		return 0
+	}
 	return value
 }
 
+// logError logs an error of a getter that cannot return it,
+// unless the samples cannot be used at all
+func (samples *Samples) logError(msg string) {
+	if samples.check() == nil {
+		samples.input.connector.log(LogError, msg)
+	}
+}
+
 // GetFloat32 is a function to retrieve a value of type float32 from the samples
 func (samples *Samples) GetFloat32(index int, fieldName string) (value float32) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = float32(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = float32(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
 // GetFloat64 is a function to retrieve a value of type float64 from the samples
 func (samples *Samples) GetFloat64(index int, fieldName string) (value float64) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = float64(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = float64(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
 // GetInt is a function to retrieve a value of type int from the samples
 func (samples *Samples) GetInt(index int, fieldName string) (value int) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = int(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = int(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
 // GetUint is a function to retrieve a value of type uint from the samples
 func (samples *Samples) GetUint(index int, fieldName string) (value uint) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = uint(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = uint(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
 // GetByte is a function to retrieve a value of type byte from the samples
 func (samples *Samples) GetByte(index int, fieldName string) (value byte) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = byte(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = byte(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
 // GetRune is a function to retrieve a value of type rune from the samples
 func (samples *Samples) GetRune(index int, fieldName string) (value rune) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return 0
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = rune(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value = rune(C.RTIDDSConnector_getNumberFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value
 }
 
 // GetBoolean is a function to retrieve a value of type boolean from the samples
 func (samples *Samples) GetBoolean(index int, fieldName string) bool {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return false
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value := int(C.RTIDDSConnector_getBooleanFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr))
+	value := int(C.RTIDDSConnector_getBooleanFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
 	return value != 0
 }
 
 // GetString is a function to retrieve a value of type string from the samples
 func (samples *Samples) GetString(index int, fieldName string) (value string) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return ""
+	}
 	fieldNameCStr := C.CString(fieldName)
 	defer C.free(unsafe.Pointer(fieldNameCStr))
 
-	value = C.GoString((*C.char)(C.RTIDDSConnector_getStringFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1), fieldNameCStr)))
+	value = C.GoString((*C.char)(C.RTIDDSConnector_getStringFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr)))
 	return value
 }
 
+// GetStringInto is a function to append a value of type string from the
+// samples to *dst. The bytes are copied from the C layer directly, so reusing
+// the same slice (e.g. buffer = buffer[:0]) across calls avoids allocations
+// once its capacity is large enough.
+func (samples *Samples) GetStringInto(index int, fieldName string, dst *[]byte) (err error) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return err
+	}
+	if dst == nil {
+		err = errors.New("Destination is null")
+		return err
+	}
+
+	fieldNameCStr := C.CString(fieldName)
+	defer C.free(unsafe.Pointer(fieldNameCStr))
+
+	valueCStr := (*C.char)(C.RTIDDSConnector_getStringFromSamples(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex, fieldNameCStr))
+	if valueCStr == nil {
+		return nil
+	}
+	*dst = append(*dst, unsafe.Slice((*byte)(unsafe.Pointer(valueCStr)), int(C.strlen(valueCStr)))...)
+	return nil
+}
+
+// GetSequenceLength is a function to retrieve the number of elements
+// of an array or a sequence member from the samples. It returns an error
+// for a member that is not in the sample or is not an array or a sequence.
+func (samples *Samples) GetSequenceLength(index int, fieldName string) (length int, err error) {
+	member, err := samples.getJSONMember(index, fieldName)
+	if err != nil {
+		return 0, err
+	}
+	if string(member) == "null" {
+		// An optional sequence without a value
+		return 0, nil
+	}
+	var elements []json.RawMessage
+	err = json.Unmarshal(member, &elements)
+	if err != nil {
+		err = errors.New("Not an array or a sequence: " + fieldName)
+		return 0, err
+	}
+	return len(elements), nil
+}
+
+// GetUint8Index is a function to retrieve an element of type uint8 of an array or a sequence from the samples
+func (samples *Samples) GetUint8Index(index int, fieldName string, elemIndex int) (value uint8) {
+	return samples.GetUint8(index, elementName(fieldName, elemIndex))
+}
+
+// GetUint16Index is a function to retrieve an element of type uint16 of an array or a sequence from the samples
+func (samples *Samples) GetUint16Index(index int, fieldName string, elemIndex int) (value uint16) {
+	return samples.GetUint16(index, elementName(fieldName, elemIndex))
+}
+
+// GetUint32Index is a function to retrieve an element of type uint32 of an array or a sequence from the samples
+func (samples *Samples) GetUint32Index(index int, fieldName string, elemIndex int) (value uint32) {
+	return samples.GetUint32(index, elementName(fieldName, elemIndex))
+}
+
+// GetInt16Index is a function to retrieve an element of type int16 of an array or a sequence from the samples
+func (samples *Samples) GetInt16Index(index int, fieldName string, elemIndex int) (value int16) {
+	return samples.GetInt16(index, elementName(fieldName, elemIndex))
+}
+
+// GetInt32Index is a function to retrieve an element of type int32 of an array or a sequence from the samples
+func (samples *Samples) GetInt32Index(index int, fieldName string, elemIndex int) (value int32) {
+	return samples.GetInt32(index, elementName(fieldName, elemIndex))
+}
+
+// GetIntIndex is a function to retrieve an element of type int of an array or a sequence from the samples
+func (samples *Samples) GetIntIndex(index int, fieldName string, elemIndex int) (value int) {
+	return samples.GetInt(index, elementName(fieldName, elemIndex))
+}
+
+// GetFloat32Index is a function to retrieve an element of type float32 of an array or a sequence from the samples
+func (samples *Samples) GetFloat32Index(index int, fieldName string, elemIndex int) (value float32) {
+	return samples.GetFloat32(index, elementName(fieldName, elemIndex))
+}
+
+// GetFloat64Index is a function to retrieve an element of type float64 of an array or a sequence from the samples
+func (samples *Samples) GetFloat64Index(index int, fieldName string, elemIndex int) (value float64) {
+	return samples.GetFloat64(index, elementName(fieldName, elemIndex))
+}
+
+// GetBooleanIndex is a function to retrieve an element of type boolean of an array or a sequence from the samples
+func (samples *Samples) GetBooleanIndex(index int, fieldName string, elemIndex int) bool {
+	return samples.GetBoolean(index, elementName(fieldName, elemIndex))
+}
+
+// GetStringIndex is a function to retrieve an element of type string of an array or a sequence from the samples
+func (samples *Samples) GetStringIndex(index int, fieldName string, elemIndex int) (value string) {
+	return samples.GetString(index, elementName(fieldName, elemIndex))
+}
+
 // GetJSON is a function to retrieve a slice of bytes of a JSON string from the samples
 func (samples *Samples) GetJSON(index int) (json []byte, e error) {
-	jsonCStr := C.RTIDDSConnector_getJSONSample(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, C.int(index+1))
-	defer C.RTIDDSConnector_freeString((*C.char)(jsonCStr))
+	e = samples.GetJSONInto(index, &json)
+	if e != nil {
+		return nil, e
+	}
+	return json, e
+}
 
-	json = []byte(C.GoString((*C.char)(jsonCStr)))
+// GetJSONInto is a function to append the JSON string of a sample to *dst.
+// The bytes are copied from the C layer directly, so reusing the same slice
+// (e.g. buffer = buffer[:0]) across calls avoids allocations once its capacity
+// is large enough.
+func (samples *Samples) GetJSONInto(index int, dst *[]byte) (err error) {
+	nativeIndex, err := samples.nativeIndex(index)
+	if err != nil {
+		return err
+	}
+	if dst == nil {
+		err = errors.New("Destination is null")
+		return err
+	}
 
-	return json, e
+	jsonCStr := (*C.char)(C.RTIDDSConnector_getJSONSample(unsafe.Pointer(samples.input.connector.native), samples.input.nameCStr, nativeIndex))
+	if jsonCStr == nil {
+		return nil
+	}
+	defer C.RTIDDSConnector_freeString(jsonCStr)
+
+	*dst = append(*dst, unsafe.Slice((*byte)(unsafe.Pointer(jsonCStr)), int(C.strlen(jsonCStr)))...)
+	return nil
+}
+
+// GetBytes is a function to retrieve an octet sequence or array from the samples as a slice of bytes
+func (samples *Samples) GetBytes(index int, fieldName string) (value []byte, err error) {
+	member, err := samples.getJSONMember(index, fieldName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Octets are represented either as an array of numbers or as a base64 string
+	if len(member) > 0 && member[0] == '[' {
+		var numbers []int
+		err = json.Unmarshal(member, &numbers)
+		if err != nil {
+			return nil, err
+		}
+		value = make([]byte, len(numbers))
+		for i, n := range numbers {
+			if n < 0 || n > 255 {
+				err = errors.New("Invalid octet value in " + fieldName)
+				return nil, err
+			}
+			value[i] = byte(n)
+		}
+		return value, nil
+	}
+
+	err = json.Unmarshal(member, &value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// GetFloat64Array is a function to retrieve a numeric array or sequence from the samples as a slice of float64
+func (samples *Samples) GetFloat64Array(index int, fieldName string) (value []float64, err error) {
+	return getArray[float64](samples, index, fieldName)
+}
+
+// GetInt32Array is a function to retrieve an integer array or sequence from the samples as a slice of int32
+func (samples *Samples) GetInt32Array(index int, fieldName string) (value []int32, err error) {
+	return getArray[int32](samples, index, fieldName)
+}
+
+// GetStringArray is a function to retrieve a string array or sequence from the samples as a slice of strings
+func (samples *Samples) GetStringArray(index int, fieldName string) (value []string, err error) {
+	return getArray[string](samples, index, fieldName)
+}
+
+// getArray retrieves a whole array or sequence member with a single parse of
+// the JSON of the sample. An empty sequence is returned as an empty slice.
+func getArray[T any](samples *Samples, index int, fieldName string) (value []T, err error) {
+	err = samples.check()
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := samples.getJSONMember(index, fieldName)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(member, &value)
+	if err != nil {
+		err = errors.New("Invalid array member " + fieldName + ": " + err.Error())
+		return nil, err
+	}
+	if value == nil {
+		value = []T{}
+	}
+	return value, nil
+}
+
+// GetWString is a function to retrieve a value of type wstring from the
+// samples as a UTF-8 Go string, through the JSON of the sample
+// (see Instance.SetWString)
+func (samples *Samples) GetWString(index int, fieldName string) (value string, err error) {
+	member, err := samples.getJSONMember(index, fieldName)
+	if err != nil {
+		return "", err
+	}
+	err = json.Unmarshal(member, &value)
+	if err != nil {
+		err = errors.New("Not a string member: " + fieldName)
+		return "", err
+	}
+	return value, nil
+}
+
+// timeMember is the JSON of a member holding a time, such as DDS Time_t
+type timeMember struct {
+	Sec     int64  `json:"sec"`
+	Nanosec uint32 `json:"nanosec"`
+}
+
+// GetTime is a function to retrieve a time from a member of the samples made
+// of the sec and nanosec members, such as DDS Time_t and Duration_t
+func (samples *Samples) GetTime(index int, fieldName string) (value time.Time, err error) {
+	member, err := samples.getJSONMember(index, fieldName)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(member, &fields)
+	if err != nil || fields["sec"] == nil || fields["nanosec"] == nil {
+		err = errors.New("Not a time member: " + fieldName)
+		return time.Time{}, err
+	}
+	var t timeMember
+	err = json.Unmarshal(member, &t)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(t.Sec, int64(t.Nanosec)), nil
+}
+
+// GetMap is a function to retrieve a map member from the samples.
+// The keys and values are those of the JSON representation of the map
+// (see Instance.SetMap): the keys are strings and the numbers are float64.
+func (samples *Samples) GetMap(index int, fieldName string) (value map[string]interface{}, err error) {
+	err = samples.check()
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := samples.getJSONMember(index, fieldName)
+	if err != nil {
+		return nil, err
+	}
+	if len(member) == 0 || member[0] != '{' {
+		err = errors.New("Not a map: " + fieldName)
+		return nil, err
+	}
+	err = json.Unmarshal(member, &value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// memberNotFoundError is returned by getJSONMember for a member that is not
+// in the JSON of a sample, e.g. an optional member without a value
+type memberNotFoundError struct {
+	fieldName string
+}
+
+func (e *memberNotFoundError) Error() string {
+	return "Invalid field name: " + e.fieldName
+}
+
+// getJSONMember retrieves the JSON of a single member from the samples.
+// Nested members are separated by dots (e.g. "pos.x").
+func (samples *Samples) getJSONMember(index int, fieldName string) (member json.RawMessage, err error) {
+	jsonData, err := samples.GetJSON(index)
+	if err != nil {
+		return nil, err
+	}
+
+	member = json.RawMessage(jsonData)
+	for _, name := range strings.Split(fieldName, ".") {
+		name, indexes, err := splitIndexes(fieldName, name)
+		if err != nil {
+			return nil, err
+		}
+		var members map[string]json.RawMessage
+		err = json.Unmarshal(member, &members)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		member, ok = members[name]
+		if !ok {
+			err = &memberNotFoundError{fieldName: fieldName}
+			return nil, err
+		}
+		for _, elemIndex := range indexes {
+			var elements []json.RawMessage
+			err = json.Unmarshal(member, &elements)
+			if err != nil {
+				return nil, err
+			}
+			if elemIndex > len(elements) {
+				err = errors.New("Index out of range for " + fieldName + ": " + strconv.Itoa(len(elements)) + " elements")
+				return nil, err
+			}
+			member = elements[elemIndex-1]
+		}
+	}
+	return member, nil
+}
+
+// splitIndexes splits a member name of fieldName, e.g. "seq[2]", into the
+// name and the 1-based indexes of the elements (see elementName)
+func splitIndexes(fieldName string, name string) (member string, indexes []int, err error) {
+	bracket := strings.IndexByte(name, '[')
+	if bracket < 0 {
+		return name, nil, nil
+	}
+	member, rest := name[:bracket], name[bracket:]
+	for rest != "" {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			err = errors.New("Invalid field name: " + fieldName)
+			return "", nil, err
+		}
+		index, err := strconv.Atoi(rest[1:end])
+		if err != nil || index < 1 {
+			err = errors.New("Invalid field name: " + fieldName)
+			return "", nil, err
+		}
+		indexes = append(indexes, index)
+		rest = rest[end+1:]
+	}
+	return member, indexes, nil
+}
+
+// GetKeyValue is a function to retrieve the JSON of the key members of a
+// sample. The key members are those declared with key="true" in the XML type.
+// For a sample without valid data (see Infos.IsValid), such as a dispose,
+// the key members are the only members filled in, so the key identifies the
+// instance that changed state.
+func (samples *Samples) GetKeyValue(index int) (jsonKey string, err error) {
+	err = samples.check()
+	if err != nil {
+		return "", err
+	}
+
+	configs, err := samples.input.connector.config()
+	if err != nil {
+		return "", err
+	}
+	typ, err := samples.input.connector.entityType(samples.input.name)
+	if err != nil {
+		return "", err
+	}
+	members, err := allMembers(configs, typ)
+	if err != nil {
+		return "", err
+	}
+
+	jsonData, err := samples.GetJSON(index)
+	if err != nil {
+		return "", err
+	}
+	var sample map[string]json.RawMessage
+	err = json.Unmarshal(jsonData, &sample)
+	if err != nil {
+		return "", err
+	}
+
+	// Build the JSON by hand to keep the order of the members in the type
+	var buffer strings.Builder
+	buffer.WriteByte('{')
+	for _, member := range members {
+		if !member.Key {
+			continue
+		}
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		name, _ := json.Marshal(member.Name)
+		buffer.Write(name)
+		buffer.WriteByte(':')
+		value, ok := sample[member.Name]
+		if !ok {
+			value = json.RawMessage("null")
+		}
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.String(), nil
+}
+
+// IsFieldSet is a function to check whether a member has a value in a sample.
+// It returns false for an optional member without a value, and an error for a
+// name that is not a member of the type of the input in the XML configuration.
+func (samples *Samples) IsFieldSet(index int, fieldName string) (set bool, err error) {
+	err = samples.check()
+	if err != nil {
+		return false, err
+	}
+
+	configs, err := samples.input.connector.config()
+	if err != nil {
+		return false, err
+	}
+	typ, err := samples.input.connector.entityType(samples.input.name)
+	if err != nil {
+		return false, err
+	}
+	_, err = findMember(configs, typ, fieldName)
+	if err != nil {
+		return false, err
+	}
+
+	// An optional member without a value is either null or missing
+	member, err := samples.getJSONMember(index, fieldName)
+	if err != nil {
+		var notFound *memberNotFoundError
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return string(member) != "null", nil
 }
 
 // Get is a function to retrieve all the information
@@ -671,7 +2616,7 @@ func (samples *Samples) Get(index int, v interface{}) (e error) {
 		return e
 	}
 
-	e = json.Unmarshal(jsonData, &v)
+	e = getCodec().Unmarshal(jsonData, &v)
 	if e != nil {
 		return e
 	}
@@ -679,12 +2624,38 @@ func (samples *Samples) Get(index int, v interface{}) (e error) {
 	return e
 }
 
+// ToMap is a function to retrieve a sample as a map. Nested structures are
+// maps, sequences and arrays are slices and numbers are json.Number so that
+// 64-bit integers are not rounded.
+func (samples *Samples) ToMap(index int) (sample map[string]interface{}, err error) {
+	jsonData, err := samples.GetJSON(index)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+	err = decoder.Decode(&sample)
+	if err != nil {
+		return nil, err
+	}
+	return sample, nil
+}
+
 // IsValid is a function to check validity of the element and return a boolean
 func (infos *Infos) IsValid(index int) (valid bool) {
+	if infos.check() != nil {
+		return false
+	}
+	nativeIndex, err := infos.input.Samples.nativeIndex(index)
+	if err != nil {
+		return false
+	}
+
 	memberNameCStr := C.CString("valid_data")
 	defer C.free(unsafe.Pointer(memberNameCStr))
 
-	if int(C.RTIDDSConnector_getBooleanFromInfos(unsafe.Pointer(infos.input.connector.native), infos.input.nameCStr, C.int(index+1), memberNameCStr)) != 0 {
+	if int(C.RTIDDSConnector_getBooleanFromInfos(unsafe.Pointer(infos.input.connector.native), infos.input.nameCStr, nativeIndex, memberNameCStr)) != 0 {
 		valid = true
 	} else {
 		valid = false
@@ -694,6 +2665,12 @@ func (infos *Infos) IsValid(index int) (valid bool) {
 
 // GetLength is a function to return the length of the
 func (infos *Infos) GetLength() (length int) {
+	if infos.check() != nil {
+		return 0
+	}
+	if infos.input.Samples.indexes != nil {
+		return len(infos.input.Samples.indexes)
+	}
 	length = int(C.RTIDDSConnector_getInfosLength(unsafe.Pointer(infos.input.connector.native), infos.input.nameCStr))
 	return length
 }
@@ -1,14 +1,28 @@
 package rti
 
 import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
 	"github.com/rticommunity/rticonnextdds-connector-go/types"
 	"github.com/stretchr/testify/assert"
 	"math"
+	"math/rand"
+	"os"
 	"path"
 	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
+//go:embed test/xml/Test.xml
+var testXML []byte
+
 // Helper functions
 func newTestConnector() (connector *Connector) {
 	_, curPath, _, _ := runtime.Caller(0)
@@ -28,6 +42,16 @@ func newTestOutput(connector *Connector) (output *Output) {
 	return output
 }
 
+func newTestComplexInput(connector *Connector) (input *Input) {
+	input, _ = connector.GetInput("MySubscriber::MyComplexReader")
+	return input
+}
+
+func newTestComplexOutput(connector *Connector) (output *Output) {
+	output, _ = connector.GetOutput("MyPublisher::MyComplexWriter")
+	return output
+}
+
 // Connector test
 func TestInvalidXMLPath(t *testing.T) {
 	participantProfile := "MyParticipantLibrary::Zero"
@@ -64,10 +88,192 @@ func TestMultipleConnectorCreation(t *testing.T) {
 	}
 }
 
+func TestConnectorReconnect(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+	reader := input.native
+	writer := output.native
+
+	err := connector.Reconnect()
+	assert.Nil(t, err)
+
+	// The *Input and *Output returned before refer to the new entities
+	assert.NotEqual(t, input.native, reader)
+	assert.NotEqual(t, output.native, writer)
+	assert.Equal(t, input.native, connector.Inputs[0].native)
+	assert.Equal(t, output.native, connector.Outputs[0].native)
+
+	// The inputs and outputs obtained before keep working
+	input.Take()
+	output.Instance.SetString("st", "reconnect")
+	err = output.Write()
+	assert.Nil(t, err)
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	err = input.Take()
+	assert.Nil(t, err)
+	assert.Equal(t, input.Samples.GetString(0, "st"), "reconnect")
+
+	// Streams are stopped
+	events, err := input.Stream(context.Background())
+	assert.Nil(t, err)
+	err = connector.Reconnect()
+	assert.Nil(t, err)
+	for range events {
+	}
+
+	var nullConnector *Connector
+	assert.NotNil(t, nullConnector.Reconnect())
+}
+
+func TestConnectorClone(t *testing.T) {
+	connector := newTestConnector()
+	clone, err := connector.Clone()
+	assert.Nil(t, err)
+	assert.NotNil(t, clone)
+	defer clone.Delete()
+
+	// The clone communicates with the original connector
+	input := newTestInput(clone)
+	output := newTestOutput(connector)
+	input.Take()
+	output.Instance.SetString("st", "clone")
+	output.Write()
+	err = clone.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Samples.GetString(0, "st"), "clone")
+	assert.Equal(t, clone.ConfigName(), connector.ConfigName())
+	assert.Equal(t, clone.ConfigURL(), connector.ConfigURL())
+	assert.Equal(t, connector.ConfigName(), "MyParticipantLibrary::Zero")
+	assert.Equal(t, connector.ConfigName(), connector.ParticipantName())
+
+	connector.Delete()
+	_, err = connector.Clone()
+	assert.NotNil(t, err)
+	var nullConnector *Connector
+	_, err = nullConnector.Clone()
+	assert.NotNil(t, err)
+	assert.Equal(t, nullConnector.ConfigName(), "")
+	assert.Equal(t, nullConnector.ConfigURL(), "")
+}
+
 func TestConnectorDeletion(t *testing.T) {
 	var nullConnector *Connector
 	err := nullConnector.Delete()
 	assert.NotNil(t, err)
+
+	// Deleting twice must not free the native resources twice
+	connector := newTestConnector()
+	newTestInput(connector)
+	newTestOutput(connector)
+	err = connector.Delete()
+	assert.Nil(t, err)
+	err = connector.Delete()
+	assert.Nil(t, err)
+}
+
+func TestInlineXMLConfiguration(t *testing.T) {
+	_, curPath, _, _ := runtime.Caller(0)
+	xmlDoc, err := os.ReadFile(path.Join(path.Dir(curPath), "./test/xml/Test.xml"))
+	assert.Nil(t, err)
+
+	connector, err := NewConnectorFromXML("MyParticipantLibrary::Zero", string(xmlDoc))
+	assert.Nil(t, err)
+	assert.NotNil(t, connector)
+	defer connector.Delete()
+
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+	assert.NotNil(t, input)
+	assert.NotNil(t, output)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+	output.Instance.SetString("st", "inline")
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Samples.GetString(0, "st"), "inline")
+
+	outputNames, err := connector.ListOutputs()
+	assert.Nil(t, err)
+	assert.Contains(t, outputNames, "MyPublisher::MyWriter")
+}
+
+func TestInvalidInlineXML(t *testing.T) {
+	invalidDocs := []string{
+		"",
+		"<dds>",
+		"<dds><types></dds>",
+		"<other></other>",
+		"not xml",
+	}
+	for _, xmlDoc := range invalidDocs {
+		connector, err := NewConnectorFromXML("MyParticipantLibrary::Zero", xmlDoc)
+		assert.NotNil(t, err, xmlDoc)
+		assert.Nil(t, connector)
+	}
+
+	// Well-formed, but without the participant
+	connector, err := NewConnectorFromXML("MyParticipantLibrary::Zero", "<dds>\n</dds>")
+	assert.NotNil(t, err)
+	assert.Nil(t, connector)
+}
+
+func TestEmbeddedXMLConfiguration(t *testing.T) {
+	connector, err := NewConnectorFromBytes("MyParticipantLibrary::Zero", testXML)
+	assert.Nil(t, err)
+	assert.NotNil(t, connector)
+	assert.NotNil(t, newTestInput(connector))
+	connector.Delete()
+
+	connector, err = NewConnectorFromReader("MyParticipantLibrary::Zero", bytes.NewReader(testXML))
+	assert.Nil(t, err)
+	assert.NotNil(t, connector)
+	assert.NotNil(t, newTestOutput(connector))
+	connector.Delete()
+
+	_, err = NewConnectorFromBytes("MyParticipantLibrary::Zero", []byte("<dds>"))
+	assert.NotNil(t, err)
+	_, err = NewConnectorFromReader("MyParticipantLibrary::Zero", nil)
+	assert.NotNil(t, err)
+}
+
+// deleteCounter counts the deletions of native connectors in a trace
+type deleteCounter struct {
+	count atomic.Int32
+}
+
+func (counter *deleteCounter) Write(line []byte) (int, error) {
+	if bytes.Contains(line, []byte("RTIDDSConnector_delete(")) {
+		counter.count.Add(1)
+	}
+	return len(line), nil
+}
+
+func TestConnectorFinalizer(t *testing.T) {
+	var counter deleteCounter
+	SetTrace(&counter)
+	defer SetTrace(nil)
+
+	for i := 0; i < 5; i++ {
+		connector := newTestConnector()
+		assert.NotNil(t, connector)
+		newTestInput(connector)
+		newTestOutput(connector)
+	}
+	// Unreachable connectors are deleted by their finalizer, even though
+	// their inputs and outputs point to them. Finalizers run in a goroutine.
+	// Connectors left by other tests may be deleted too.
+	for i := 0; i < 100 && counter.count.Load() < 5; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, counter.count.Load() >= 5)
 }
 
 // Input tests
@@ -140,8 +346,8 @@ func TestDataFlow(t *testing.T) {
 	us := uint16(math.MaxUint16)
 	l := int32(math.MaxInt32)
 	ul := uint32(math.MaxUint32)
-	//ll := int64(math.MaxInt64)
-	//ull := uint64(math.MaxUint64)
+	ll := int64(math.MaxInt64)
+	ull := uint64(math.MaxUint64)
 	f := float32(math.MaxFloat32)
 	d := float64(math.MaxFloat64)
 
@@ -160,8 +366,8 @@ func TestDataFlow(t *testing.T) {
 	output.Instance.SetInt("l", int(l))
 	output.Instance.SetUint("ul", uint(ul))
 	output.Instance.SetRune("l", rune(l))
-	//output.Instance.SetInt64("ll", ll)
-	//output.Instance.SetUint64("ull", ull)
+	output.Instance.SetInt64("ll", ll)
+	output.Instance.SetUint64("ull", ull)
 	output.Instance.SetFloat32("f", f)
 	output.Instance.SetFloat64("d", d)
 
@@ -192,8 +398,8 @@ func TestDataFlow(t *testing.T) {
 	assert.Equal(t, input.Samples.GetUint(0, "ul"), uint(ul))
 	assert.Equal(t, input.Samples.GetRune(0, "l"), rune(l))
 	assert.Equal(t, input.Samples.GetUint32(0, "ul"), ul)
-	//assert.Equal(t, input.Samples.GetInt64(0, "ll"), ll)
-	//assert.Equal(t, input.Samples.GetUint64(0, "ull"), ull)
+	assert.Equal(t, input.Samples.GetInt64(0, "ll"), ll)
+	assert.Equal(t, input.Samples.GetUint64(0, "ull"), ull)
 	assert.Equal(t, input.Samples.GetFloat32(0, "f"), f)
 	assert.Equal(t, input.Samples.GetFloat64(0, "d"), d)
 
@@ -229,3 +435,2642 @@ func TestJSON(t *testing.T) {
 	assert.Equal(t, inputTestData.St, outputTestData.St)
 
 }
+
+func TestWaitWithContext(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	// Deadline reached without data
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	err := connector.WaitWithContext(ctx)
+	cancel()
+	assert.Equal(t, err, ErrTimeout)
+
+	// Under a millisecond left: a single wait instead of spinning
+	var buffer bytes.Buffer
+	SetTrace(&buffer)
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Microsecond)
+	err = connector.WaitWithContext(ctx)
+	cancel()
+	SetTrace(nil)
+	assert.Equal(t, err, ErrTimeout)
+	assert.True(t, strings.Count(buffer.String(), "RTIDDSConnector_wait(") <= 1)
+
+	// Cancelled while blocked
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	err = connector.WaitWithContext(ctx)
+	assert.Equal(t, err, context.Canceled)
+
+	// Data available
+	output.Instance.SetString("st", "test")
+	output.Write()
+	err = connector.WaitWithContext(context.Background())
+	assert.Nil(t, err)
+
+	var nullConnector *Connector
+	err = nullConnector.WaitWithContext(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestStream(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := input.Stream(ctx)
+	assert.Nil(t, err)
+	assert.NotNil(t, events)
+
+	var outputTestData types.Test
+	outputTestData.St = "test"
+	output.Instance.Set(&outputTestData)
+	output.Write()
+
+	event := <-events
+	assert.Equal(t, event.Valid, true)
+	var inputTestData types.Test
+	json.Unmarshal(event.JSON, &inputTestData)
+	assert.Equal(t, inputTestData.St, outputTestData.St)
+
+	// The channel is closed on cancellation
+	cancel()
+	for range events {
+	}
+
+	var nullInput *Input
+	events, err = nullInput.Stream(context.Background())
+	assert.Nil(t, events)
+	assert.NotNil(t, err)
+}
+
+func TestStreamClosedOnDelete(t *testing.T) {
+	connector := newTestConnector()
+	input := newTestInput(connector)
+
+	events, err := input.Stream(context.Background())
+	assert.Nil(t, err)
+
+	connector.Delete()
+	_, ok := <-events
+	assert.Equal(t, ok, false)
+
+	// A stream cannot be started on a deleted connector
+	events, err = input.Stream(context.Background())
+	assert.Nil(t, events)
+	assert.NotNil(t, err)
+}
+
+func TestStreamDataOnOtherInput(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	complexInput := newTestComplexInput(connector)
+	complexOutput := newTestComplexOutput(connector)
+
+	// Data that is not taken on another input wakes up every wait
+	complexOutput.Instance.SetInt32("id", 1)
+	complexOutput.Write()
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+
+	var buffer bytes.Buffer
+	SetTrace(&buffer)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	events, err := input.Stream(ctx)
+	assert.Nil(t, err)
+	for range events {
+	}
+	cancel()
+	SetTrace(nil)
+
+	// The stream pauses between the waits instead of spinning
+	waits := strings.Count(buffer.String(), "RTIDDSConnector_wait(")
+	assert.True(t, waits > 0)
+	assert.True(t, waits < 100)
+	complexInput.Take()
+}
+
+func TestSafeOutputInput(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input, err := connector.NewSafeInput("MySubscriber::MyReader")
+	assert.Nil(t, err)
+	output, err := connector.NewSafeOutput("MyPublisher::MyWriter")
+	assert.Nil(t, err)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	const numWriters = 4
+	var wg sync.WaitGroup
+	for i := 0; i < numWriters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var outputTestData types.Test
+			outputTestData.St = "test"
+			err := output.WriteSample(&outputTestData)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	received := 0
+	for received < numWriters {
+		err = connector.Wait(-1)
+		assert.Nil(t, err)
+		input.Take()
+		input.Do(func(samples *Samples, infos *Infos) error {
+			received += samples.GetLength()
+			return nil
+		})
+	}
+	assert.Equal(t, received, numWriters)
+
+	_, err = connector.NewSafeInput("invalidDR")
+	assert.NotNil(t, err)
+	var nullOutput *SafeOutput
+	assert.NotNil(t, nullOutput.Write())
+}
+
+func TestSafeOutputWriteWithParams(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input, err := connector.NewSafeInput("MySubscriber::MyReader")
+	assert.Nil(t, err)
+	output, err := connector.NewSafeOutput("MyPublisher::MyWriter")
+	assert.Nil(t, err)
+	assert.Equal(t, output.Name(), "MyPublisher::MyWriter")
+	assert.Equal(t, input.Name(), "MySubscriber::MyReader")
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	// Run with -race (see run_test.sh) to detect unserialized writes
+	const numWriters = 8
+	const numWrites = 50
+	var wg sync.WaitGroup
+	for i := 0; i < numWriters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < numWrites; j++ {
+				err := output.WriteWithParams(`{"action":"write"}`)
+				assert.Nil(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var nullOutput *SafeOutput
+	assert.NotNil(t, nullOutput.WriteWithParams("{}"))
+}
+
+func TestSequenceElements(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestComplexInput(connector)
+	output := newTestComplexOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetJSON([]byte(`{"id":1,"int_array":[1,2,3,4,5],"int_seq":[10,20,30]}`))
+	output.Write()
+
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	length, err := input.Samples.GetSequenceLength(0, "int_seq")
+	assert.Nil(t, err)
+	assert.Equal(t, length, 3)
+	for i := 0; i < length; i++ {
+		assert.Equal(t, input.Samples.GetInt32Index(0, "int_seq", i), int32((i+1)*10))
+	}
+
+	length, err = input.Samples.GetSequenceLength(0, "int_array")
+	assert.Nil(t, err)
+	assert.Equal(t, length, 5)
+	_, err = input.Samples.GetSequenceLength(0, "invalid")
+	assert.NotNil(t, err)
+	_, err = input.Samples.GetSequenceLength(0, "id")
+	assert.NotNil(t, err)
+	assert.Equal(t, input.Samples.GetInt32Index(0, "int_array", 0), int32(1))
+	assert.Equal(t, input.Samples.GetInt32Index(0, "int_array", 4), int32(5))
+	assert.Equal(t, input.Samples.GetFloat64Index(0, "int_array", 2), float64(3))
+
+	ints, err := input.Samples.GetInt32Array(0, "int_seq")
+	assert.Nil(t, err)
+	assert.Equal(t, ints, []int32{10, 20, 30})
+	floats, err := input.Samples.GetFloat64Array(0, "int_array")
+	assert.Nil(t, err)
+	assert.Equal(t, floats, []float64{1, 2, 3, 4, 5})
+	_, err = input.Samples.GetStringArray(0, "int_seq")
+	assert.NotNil(t, err)
+	_, err = input.Samples.GetInt32Array(0, "invalid")
+	assert.NotNil(t, err)
+
+	// The 64-bit getters read the elements from the JSON sample, and log
+	// the errors instead of falling back to a double
+	assert.Equal(t, input.Samples.GetInt64(0, "int_seq[2]"), int64(20))
+	assert.Equal(t, input.Samples.GetUint64(0, "int_array[5]"), uint64(5))
+	var logged []string
+	SetLogHandler(func(level LogLevel, msg string) {
+		logged = append(logged, msg)
+	})
+	assert.Equal(t, input.Samples.GetInt64(0, "int_seq[4]"), int64(0))
+	assert.Equal(t, input.Samples.GetUint64(0, "invalid"), uint64(0))
+	SetLogHandler(nil)
+	assert.Equal(t, len(logged), 2)
+
+	// Empty sequence
+	output.Instance.SetJSON([]byte(`{"id":2,"int_seq":[]}`))
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	ints, err = input.Samples.GetInt32Array(0, "int_seq")
+	assert.Nil(t, err)
+	assert.Equal(t, len(ints), 0)
+	assert.NotNil(t, ints)
+}
+
+func TestStringBound(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	// st is declared with stringMaxLength="128"
+	err := output.Instance.SetString("st", strings.Repeat("a", 129))
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "String too long for st: 129 bytes, the maximum length is 128")
+	err = output.Instance.ValidateJSON([]byte(`{"st":"` + strings.Repeat("a", 129) + `"}`))
+	assert.NotNil(t, err)
+
+	err = output.Instance.SetString("st", strings.Repeat("a", 128))
+	assert.Nil(t, err)
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Samples.GetString(0, "st"), strings.Repeat("a", 128))
+}
+
+func TestMemberLookups(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	output := newTestOutput(connector)
+	complexOutput := newTestComplexOutput(connector)
+
+	// The XML configuration is parsed once, whichever goroutine comes first
+	// (run with -race, see run_test.sh)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, output.checkString("st", "a"))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, complexOutput.checkString("wstr", "a"))
+		}()
+	}
+	wg.Wait()
+
+	// A failed lookup is logged the first time only
+	var logged []string
+	SetLogHandler(func(level LogLevel, msg string) {
+		logged = append(logged, msg)
+	})
+	defer SetLogHandler(nil)
+	assert.Nil(t, output.checkString("unknown", "a"))
+	assert.Nil(t, output.checkString("unknown", "b"))
+	assert.Equal(t, len(logged), 1)
+	assert.True(t, strings.Contains(logged[0], "unknown"))
+
+	// The indexes of the elements share the lookup of the member
+	assert.Equal(t, memberPath("int_seq[1]"), "int_seq")
+	assert.Equal(t, memberPath("pos[2].x"), "pos.x")
+	assert.Equal(t, memberPath("a[1][2].b[3]"), "a.b")
+	assert.Equal(t, memberPath("st"), "st")
+}
+
+func TestUTF8String(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	for _, text := range []string{"ÀÉÎÕÜ ñ ß", "Ελληνικά Кириллица", "中文 日本語 한국어", "🎉🚀 \u00e9 \"quoted\""} {
+		err := output.Instance.SetString("st", text)
+		assert.Nil(t, err)
+		output.Write()
+		err = connector.Wait(-1)
+		assert.Nil(t, err)
+		input.Take()
+		value := input.Samples.GetString(0, "st")
+		assert.Equal(t, []byte(value), []byte(text))
+
+		var buffer []byte
+		err = input.Samples.GetStringInto(0, "st", &buffer)
+		assert.Nil(t, err)
+		assert.Equal(t, buffer, []byte(text))
+	}
+
+	// An embedded NUL character would truncate the C string
+	err := output.Instance.SetString("st", "before\x00after")
+	assert.NotNil(t, err)
+	err = output.Instance.SetWString("st", "before\x00after")
+	assert.NotNil(t, err)
+}
+
+func TestWideString(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestComplexInput(connector)
+	output := newTestComplexOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	text := "héllo 世界 こんにちは"
+	err := output.Instance.SetWString("wstr", text)
+	assert.Nil(t, err)
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	value, err := input.Samples.GetWString(0, "wstr")
+	assert.Nil(t, err)
+	assert.Equal(t, value, text)
+
+	// The bound of a wstring is in characters
+	err = output.Instance.SetWString("wstr", strings.Repeat("世", 65))
+	assert.NotNil(t, err)
+	err = output.Instance.SetWString("wstr", strings.Repeat("世", 64))
+	assert.Nil(t, err)
+	_, err = input.Samples.GetWString(0, "id")
+	assert.NotNil(t, err)
+
+	// Strings are UTF-8, so emoji and CJK are preserved as well
+	testInput := newTestInput(connector)
+	testOutput := newTestOutput(connector)
+	testInput.Take()
+	text = "emoji 🎉 漢字"
+	testOutput.Instance.SetString("st", text)
+	testOutput.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	testInput.Take()
+	assert.Equal(t, testInput.Samples.GetString(0, "st"), text)
+	value, err = testInput.Samples.GetWString(0, "st")
+	assert.Nil(t, err)
+	assert.Equal(t, value, text)
+}
+
+func TestTimeMember(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestComplexInput(connector)
+	output := newTestComplexOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	stamp := time.Unix(1500000000, 123456789)
+	err := output.Instance.SetTime("stamp", stamp)
+	assert.Nil(t, err)
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	value, err := input.Samples.GetTime(0, "stamp")
+	assert.Nil(t, err)
+	assert.Equal(t, value.Equal(stamp), true)
+	assert.Equal(t, input.Samples.GetUint32(0, "stamp.nanosec"), uint32(123456789))
+
+	_, err = input.Samples.GetTime(0, "id")
+	assert.NotNil(t, err)
+	_, err = input.Samples.GetTime(0, "invalid")
+	assert.NotNil(t, err)
+	var nilInstance *Instance
+	err = nilInstance.SetTime("stamp", stamp)
+	assert.NotNil(t, err)
+}
+
+func TestSetArrays(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestComplexInput(connector)
+	output := newTestComplexOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	err := output.Instance.SetInt32Array("int_seq", []int32{10, 20, 30})
+	assert.Nil(t, err)
+	err = output.Instance.SetFloat64Array("int_array", []float64{1, 2, 3, 4, 5})
+	assert.Nil(t, err)
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	ints, err := input.Samples.GetInt32Array(0, "int_seq")
+	assert.Nil(t, err)
+	assert.Equal(t, ints, []int32{10, 20, 30})
+	ints, err = input.Samples.GetInt32Array(0, "int_array")
+	assert.Nil(t, err)
+	assert.Equal(t, ints, []int32{1, 2, 3, 4, 5})
+
+	err = output.Instance.SetInt32Array("int_array", make([]int32, 6))
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "Too many elements for int_array: 6, the bound is 5")
+	err = output.Instance.SetInt32Array("int_seq", make([]int32, 11))
+	assert.NotNil(t, err)
+	err = output.Instance.SetStringArray("id", []string{"a"})
+	assert.NotNil(t, err)
+	err = output.Instance.SetInt32Array("invalid", nil)
+	assert.NotNil(t, err)
+	var nilInstance *Instance
+	err = nilInstance.SetInt32Array("int_seq", nil)
+	assert.NotNil(t, err)
+}
+
+func TestTypedInputOutput(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input, err := GetTypedInput[types.Test](connector, "MySubscriber::MyReader")
+	assert.Nil(t, err)
+	output, err := GetTypedOutput[types.Test](connector, "MyPublisher::MyWriter")
+	assert.Nil(t, err)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	outputTestData := types.Test{St: "test", L: 42, Ll: math.MaxInt64}
+	err = output.Write(outputTestData)
+	assert.Nil(t, err)
+
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	values, infos, err := input.Take()
+	assert.Nil(t, err)
+	assert.Equal(t, len(values), 1)
+	assert.Equal(t, len(infos), 1)
+	assert.Equal(t, infos[0].Valid, true)
+	assert.Equal(t, values[0], outputTestData)
+
+	_, err = GetTypedInput[types.Test](connector, "invalidDR")
+	assert.NotNil(t, err)
+}
+
+func TestTakeNoData(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	err := input.Take()
+	assert.Equal(t, err, ErrNoData)
+	assert.True(t, errors.Is(err, ErrNoData))
+	err = input.Read()
+	assert.True(t, errors.Is(err, ErrNoData))
+	count, err := input.TakeCount()
+	assert.Equal(t, count, 0)
+	assert.True(t, errors.Is(err, ErrNoData))
+
+	output.Instance.SetString("st", "test")
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	count, err = input.TakeCount()
+	assert.Nil(t, err)
+	assert.Equal(t, count, 1)
+
+	var nullInput *Input
+	_, err = nullInput.TakeCount()
+	assert.NotNil(t, err)
+}
+
+func TestSampleIterator(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	for i := 0; i < 3; i++ {
+		output.Instance.SetInt32("l", int32(i))
+		output.Write()
+	}
+
+	received := 0
+	for received < 3 {
+		err := connector.Wait(-1)
+		assert.Nil(t, err)
+		input.Read()
+		received = input.Samples.GetLength()
+	}
+	input.Take()
+
+	it := input.Iterate()
+	count := 0
+	for it.Next() {
+		assert.Equal(t, it.Index(), count)
+		assert.Equal(t, it.Valid(), true)
+		assert.Equal(t, it.Samples().GetInt32(it.Index(), "l"), int32(count))
+		count++
+	}
+	assert.Equal(t, count, 3)
+	assert.Equal(t, it.Next(), false)
+
+	count = 0
+	for i, valid := range input.Samples.All() {
+		assert.Equal(t, i, count)
+		assert.Equal(t, valid, true)
+		count++
+	}
+	assert.Equal(t, count, 3)
+}
+
+func TestBytes(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestComplexInput(connector)
+	output := newTestComplexOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	payload := make([]byte, 1024)
+	rand.Read(payload)
+	err := output.Instance.SetBytes("payload", payload)
+	assert.Nil(t, err)
+	output.Write()
+
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	received, err := input.Samples.GetBytes(0, "payload")
+	assert.Nil(t, err)
+	assert.Equal(t, received, payload)
+
+	_, err = input.Samples.GetBytes(0, "invalid")
+	assert.NotNil(t, err)
+}
+
+func TestListInputsOutputs(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+
+	inputNames, err := connector.ListInputs()
+	assert.Nil(t, err)
+	assert.Equal(t, inputNames, []string{"MySubscriber::MyReader", "MySubscriber::MyComplexReader"})
+
+	outputNames, err := connector.ListOutputs()
+	assert.Nil(t, err)
+	assert.Equal(t, outputNames, []string{"MyPublisher::MyWriter", "MyPublisher::MyComplexWriter"})
+
+	for _, outputName := range outputNames {
+		output, err := connector.GetOutput(outputName)
+		assert.NotNil(t, output)
+		assert.Nil(t, err)
+	}
+
+	var nullConnector *Connector
+	_, err = nullConnector.ListInputs()
+	assert.NotNil(t, err)
+}
+
+func TestTakeJSONAndTakeInto(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	for _, st := range []string{"a", "b"} {
+		output.Instance.SetString("st", st)
+		output.Write()
+	}
+	for received := 0; received < 2; received = input.Samples.GetLength() {
+		connector.Wait(-1)
+		input.Read()
+	}
+	jsonSamples, err := input.TakeJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, len(jsonSamples), 2)
+
+	for _, st := range []string{"c", "d"} {
+		output.Instance.SetString("st", st)
+		output.Write()
+	}
+	for received := 0; received < 2; received = input.Samples.GetLength() {
+		connector.Wait(-1)
+		input.Read()
+	}
+	var values []types.Test
+	err = input.TakeInto(&values)
+	assert.Nil(t, err)
+	assert.Equal(t, len(values), 2)
+	assert.Equal(t, values[0].St, "c")
+	assert.Equal(t, values[1].St, "d")
+
+	err = input.TakeInto(&values)
+	assert.Equal(t, err, ErrNoData)
+}
+
+func TestWriteWith(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	outputTestData := types.Test{St: "test"}
+	err := output.WriteWith(&outputTestData, WriteParams{SourceTimestamp: time.Now()})
+	assert.Nil(t, err)
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Infos.IsValid(0), true)
+
+	// A dispose is received as a sample without valid data
+	err = output.WriteWith(&outputTestData, WriteParams{Action: "dispose"})
+	assert.Nil(t, err)
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Infos.IsValid(0), false)
+
+	var nullOutput *Output
+	err = nullOutput.WriteWith(&outputTestData, WriteParams{})
+	assert.NotNil(t, err)
+}
+
+func TestWriteParamsBuilder(t *testing.T) {
+	identity := Identity{SequenceNumber: 7}
+	identity.WriterGUID[15] = 1
+	params := WriteParams{}.WithUnregister().WithRelatedIdentity(identity)
+	jsonParams, err := params.ToJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(jsonParams), `{"action":"unregister","related_sample_identity":{"writer_guid":[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,1],"sequence_number":7}}`)
+
+	params = WriteParams{}.WithDispose().WithSourceTimestamp(time.Unix(1, 0)).WithIdentity(identity)
+	jsonParams, err = params.ToJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, string(jsonParams), `{"action":"dispose","identity":{"writer_guid":[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,1],"sequence_number":7},"source_timestamp":1000000000}`)
+
+	connector := newTestConnector()
+	defer connector.Delete()
+	output := newTestOutput(connector)
+	output.Instance.SetString("st", "test")
+	err = output.WriteWithParamsStruct(WriteParams{}.WithSourceTimestamp(time.Now()))
+	assert.Nil(t, err)
+}
+
+func TestIdentityString(t *testing.T) {
+	var identity Identity
+	for i := range identity.WriterGUID {
+		identity.WriterGUID[i] = byte(i)
+	}
+	identity.SequenceNumber = 42
+
+	assert.Equal(t, identity.GUIDString(), "00010203.04050607.08090a0b.0c0d0e0f")
+	assert.Equal(t, identity.String(), "00010203.04050607.08090a0b.0c0d0e0f:42")
+
+	parsed, err := ParseIdentity(identity.String())
+	assert.Nil(t, err)
+	assert.Equal(t, parsed, identity)
+
+	for _, invalid := range []string{"", "00010203:1", "00010203.04050607.08090a0b.0c0d0e0f", "00010203.04050607.08090a0b.0c0d0e0f:x", "zz010203.04050607.08090a0b.0c0d0e0f:1"} {
+		_, err = ParseIdentity(invalid)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestGetFieldType(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestComplexInput(connector)
+	output := newTestComplexOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetJSON([]byte(`{"id":1,"int_seq":[10,20,30],"color":"BLUE"}`))
+	output.Write()
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	kind, err := input.Samples.GetFieldType(0, "id")
+	assert.Nil(t, err)
+	assert.Equal(t, kind, FieldInt)
+	kind, err = input.Samples.GetFieldType(0, "int_seq")
+	assert.Nil(t, err)
+	assert.Equal(t, kind, FieldSequence)
+	kind, err = input.Samples.GetFieldType(0, "int_seq[1]")
+	assert.Nil(t, err)
+	assert.Equal(t, kind, FieldInt)
+
+	// Arrays and enums are told apart with the XML type
+	kind, err = input.Samples.GetFieldType(0, "int_array")
+	assert.Nil(t, err)
+	assert.Equal(t, kind, FieldArray)
+	kind, err = input.Samples.GetFieldType(0, "int_array[0]")
+	assert.Nil(t, err)
+	assert.Equal(t, kind, FieldInt)
+	kind, err = input.Samples.GetFieldType(0, "color")
+	assert.Nil(t, err)
+	assert.Equal(t, kind, FieldEnum)
+	_, err = input.Samples.GetFieldType(0, "invalid")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, jsonKind([]byte(`"test"`)), FieldString)
+	assert.Equal(t, jsonKind([]byte(`1.5e3`)), FieldFloat)
+	assert.Equal(t, jsonKind([]byte(`true`)), FieldBool)
+	assert.Equal(t, jsonKind([]byte(`{"x":1}`)), FieldStruct)
+	assert.Equal(t, FieldStruct.String(), "Struct")
+}
+
+func TestToMap(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.Set(&types.Test{St: "test", Ll: math.MaxInt64})
+	output.Write()
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	sample, err := input.Samples.ToMap(0)
+	assert.Nil(t, err)
+	assert.Equal(t, sample["st"], "test")
+	assert.Equal(t, sample["ll"], json.Number("9223372036854775807"))
+}
+
+func TestTakeFiltered(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	for _, l := range []int32{10, 60, 20, 80} {
+		output.Instance.SetInt32("l", l)
+		output.Write()
+	}
+	for received := 0; received < 4; received = input.Samples.GetLength() {
+		connector.Wait(-1)
+		input.Read()
+	}
+
+	err := input.TakeFiltered("l > %0", []string{"50"})
+	assert.Nil(t, err)
+	assert.Equal(t, input.Samples.GetLength(), 2)
+	assert.Equal(t, input.Infos.GetLength(), 2)
+	assert.Equal(t, input.Samples.GetInt32(0, "l"), int32(60))
+	assert.Equal(t, input.Samples.GetInt32(1, "l"), int32(80))
+
+	// All the samples were taken
+	err = input.Take()
+	assert.Equal(t, err, ErrNoData)
+
+	_, err = parseFilter("l >", nil)
+	assert.NotNil(t, err)
+	err = input.TakeFiltered("l > %1", []string{"50"})
+	assert.NotNil(t, err)
+}
+
+func TestGetKeyValue(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestComplexInput(connector)
+	output := newTestComplexOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetInt32("id", 7)
+	output.Write()
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	jsonKey, err := input.Samples.GetKeyValue(0)
+	assert.Nil(t, err)
+	assert.Equal(t, jsonKey, `{"id":7}`)
+
+	// The key can still be requested for a disposed instance
+	output.WriteWithParamsStruct(WriteParams{}.WithDispose())
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Infos.IsValid(0), false)
+	jsonKey, err = input.Samples.GetKeyValue(0)
+	assert.Nil(t, err)
+	assert.Equal(t, jsonKey, `{"id":7}`)
+
+	var nullSamples *Samples
+	_, err = nullSamples.GetKeyValue(0)
+	assert.NotNil(t, err)
+}
+
+func TestKeyJSON(t *testing.T) {
+	jsonKey, err := KeyJSON(types.Test{St: "key", L: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, string(jsonKey), `{"st":"key"}`)
+	jsonKey, err = KeyJSON(&struct {
+		ID   int32  `json:"id" dds:"key"`
+		Name string `dds:"key"`
+		Data string `json:"data"`
+	}{ID: 1, Name: "a"})
+	assert.Nil(t, err)
+	assert.Equal(t, string(jsonKey), `{"id":1,"Name":"a"}`)
+	_, err = KeyJSON(struct{ L int32 }{})
+	assert.NotNil(t, err)
+	_, err = KeyJSON(1)
+	assert.NotNil(t, err)
+	var nilTest *types.Test
+	_, err = KeyJSON(nilTest)
+	assert.NotNil(t, err)
+	_, err = KeyJSON(nil)
+	assert.NotNil(t, err)
+	_, err = KeyJSON([]int32{1})
+	assert.NotNil(t, err)
+
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	sample := types.Test{St: "instance", L: 1}
+	err = output.Instance.Set(sample)
+	assert.Nil(t, err)
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	err = output.DisposeInstance(nil)
+	assert.NotNil(t, err)
+	err = output.UnregisterInstance(nil)
+	assert.NotNil(t, err)
+
+	err = output.DisposeInstance(sample)
+	assert.Nil(t, err)
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Infos.IsValid(0), false)
+	keyValue, err := input.Samples.GetKeyValue(0)
+	assert.Nil(t, err)
+	assert.Equal(t, keyValue, `{"st":"instance"}`)
+
+	err = output.UnregisterInstance(sample)
+	assert.Nil(t, err)
+	err = output.DisposeInstance(1)
+	assert.NotNil(t, err)
+	var nilOutput *Output
+	err = nilOutput.DisposeInstance(sample)
+	assert.NotNil(t, err)
+}
+
+func TestTrace(t *testing.T) {
+	var buffer bytes.Buffer
+	SetTrace(&buffer)
+	defer SetTrace(nil)
+
+	connector := newTestConnector()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+	output.Instance.Set(map[string]string{"st": "trace"})
+	output.Write()
+	connector.Wait(0)
+	input.Take()
+	connector.Delete()
+
+	trace := buffer.String()
+	for _, function := range []string{"new", "getReader", "getWriter", "setJSONInstance", "write", "wait", "take", "delete"} {
+		assert.Contains(t, trace, "RTIDDSConnector_"+function+"(")
+	}
+	assert.Contains(t, trace, `RTIDDSConnector_take("MySubscriber::MyReader")`)
+	assert.Contains(t, trace, `{"st":"trace"}`)
+
+	// Nothing is traced once disabled
+	SetTrace(nil)
+	buffer.Reset()
+	connector = newTestConnector()
+	connector.Delete()
+	assert.Equal(t, buffer.Len(), 0)
+}
+
+func TestDDSError(t *testing.T) {
+	assert.Nil(t, checkRetcode(RetcodeOk, "test"))
+	assert.Equal(t, checkRetcode(RetcodeTimeout, "test"), ErrTimeout)
+	assert.Equal(t, checkRetcode(RetcodeNoData, "test"), ErrNoData)
+
+	err := checkRetcode(RetcodeOutOfResources, "test")
+	var ddsError *DDSError
+	assert.True(t, errors.As(err, &ddsError))
+	assert.Equal(t, ddsError.Code, RetcodeOutOfResources)
+	assert.Equal(t, err.Error(), "test: DDS_RETCODE_OUT_OF_RESOURCES")
+	assert.False(t, errors.Is(err, ErrTimeout))
+
+	assert.True(t, errors.Is(&DDSError{Code: RetcodeTimeout}, ErrTimeout))
+	assert.True(t, errors.Is(&DDSError{Code: RetcodeNoData}, ErrNoData))
+	assert.Equal(t, (&DDSError{Code: 42, Message: "test"}).Error(), "test: DDS_RETCODE_42")
+}
+
+func TestLogHandler(t *testing.T) {
+	var levels []LogLevel
+	var messages []string
+	SetLogHandler(func(level LogLevel, msg string) {
+		levels = append(levels, level)
+		messages = append(messages, msg)
+	})
+	defer SetLogHandler(nil)
+
+	connector := newTestConnector()
+	defer connector.Delete()
+	_, err := connector.GetInput("invalidDR")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, levels, []LogLevel{LogError})
+	assert.Equal(t, messages, []string{"Invalid Subscription::DataReader name: invalidDR"})
+	assert.Equal(t, LogWarning.String(), "WARNING")
+}
+
+func TestConnectorOptions(t *testing.T) {
+	_, curPath, _, _ := runtime.Caller(0)
+	xmlPath := path.Join(path.Dir(curPath), "./test/xml/Test.xml")
+	participantProfile := "MyParticipantLibrary::Zero"
+
+	var messages []string
+	SetLogHandler(func(level LogLevel, msg string) {
+		messages = append(messages, msg)
+	})
+	defer SetLogHandler(nil)
+
+	// Verbosity
+	connector, err := NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{Verbosity: LogSilent})
+	assert.Nil(t, err)
+	connector.GetInput("invalidDR")
+	assert.Equal(t, len(messages), 0)
+	connector.Delete()
+
+	connector, err = NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{Verbosity: LogInfo})
+	assert.Nil(t, err)
+	connector.GetInput("invalidDR")
+	assert.Equal(t, len(messages), 1)
+	connector.Delete()
+
+	_, err = NewConnectorWithOptions(participantProfile, "invalid/path/to/xml", ConnectorOptions{})
+	assert.NotNil(t, err)
+	assert.Equal(t, len(messages), 2)
+
+	// One-based indexes
+	connector, err = NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{OneBasedIndex: true})
+	assert.Nil(t, err)
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+	input.Take()
+	output.Instance.SetString("st", "test")
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Infos.IsValid(1), true)
+	assert.Equal(t, input.Samples.GetString(1, "st"), "test")
+	it := input.Iterate()
+	assert.True(t, it.Next())
+	assert.Equal(t, it.Index(), 1)
+
+	// On-data-available events
+	connector, err = NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{DisableOnDataEvent: true})
+	assert.Nil(t, err)
+	err = connector.Delete()
+	assert.Nil(t, err)
+}
+
+func TestGetDomainID(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+
+	domainID, err := connector.GetDomainID()
+	assert.Nil(t, err)
+	assert.Equal(t, domainID, 0)
+	assert.Equal(t, connector.ParticipantName(), "MyParticipantLibrary::Zero")
+
+	var nilConnector *Connector
+	_, err = nilConnector.GetDomainID()
+	assert.NotNil(t, err)
+}
+
+func TestNilReceivers(t *testing.T) {
+	outputCalls := map[string]func(output *Output) error{
+		"Write":           func(output *Output) error { return output.Write() },
+		"WriteWithParams": func(output *Output) error { return output.WriteWithParams("{}") },
+		"ClearMembers":    func(output *Output) error { return output.ClearMembers() },
+	}
+	for _, output := range []*Output{nil, {}} {
+		for name, call := range outputCalls {
+			assert.NotNil(t, call(output), name)
+		}
+	}
+
+	instanceCalls := map[string]func(instance *Instance) error{
+		"SetUint8":   func(instance *Instance) error { return instance.SetUint8("x", 1) },
+		"SetUint16":  func(instance *Instance) error { return instance.SetUint16("x", 1) },
+		"SetUint32":  func(instance *Instance) error { return instance.SetUint32("x", 1) },
+		"SetUint64":  func(instance *Instance) error { return instance.SetUint64("x", 1) },
+		"SetInt8":    func(instance *Instance) error { return instance.SetInt8("x", 1) },
+		"SetInt16":   func(instance *Instance) error { return instance.SetInt16("x", 1) },
+		"SetInt32":   func(instance *Instance) error { return instance.SetInt32("x", 1) },
+		"SetInt64":   func(instance *Instance) error { return instance.SetInt64("x", 1) },
+		"SetUint":    func(instance *Instance) error { return instance.SetUint("x", 1) },
+		"SetInt":     func(instance *Instance) error { return instance.SetInt("x", 1) },
+		"SetFloat32": func(instance *Instance) error { return instance.SetFloat32("x", 1) },
+		"SetFloat64": func(instance *Instance) error { return instance.SetFloat64("x", 1) },
+		"SetString":  func(instance *Instance) error { return instance.SetString("x", "a") },
+		"SetByte":    func(instance *Instance) error { return instance.SetByte("x", 1) },
+		"SetRune":    func(instance *Instance) error { return instance.SetRune("x", 1) },
+		"SetBoolean": func(instance *Instance) error { return instance.SetBoolean("x", true) },
+		"SetJSON":    func(instance *Instance) error { return instance.SetJSON([]byte("{}")) },
+		"SetBytes":   func(instance *Instance) error { return instance.SetBytes("x", []byte{1}) },
+		"SetMap":     func(instance *Instance) error { return instance.SetMap("x", nil) },
+		"Set":        func(instance *Instance) error { return instance.Set(map[string]int{"x": 1}) },
+	}
+	for _, instance := range []*Instance{nil, {}} {
+		for name, call := range instanceCalls {
+			assert.NotNil(t, call(instance), name)
+		}
+	}
+
+	inputCalls := map[string]func(input *Input) error{
+		"Read":             func(input *Input) error { return input.Read() },
+		"Take":             func(input *Input) error { return input.Take() },
+		"TakeCount":        func(input *Input) error { _, err := input.TakeCount(); return err },
+		"TakeJSON":         func(input *Input) error { _, err := input.TakeJSON(); return err },
+		"TakeInto":         func(input *Input) error { var v []interface{}; return input.TakeInto(&v) },
+		"TakeFiltered":     func(input *Input) error { return input.TakeFiltered("x = 1", nil) },
+		"ReadFiltered":     func(input *Input) error { return input.ReadFiltered("x = 1", nil) },
+		"AsyncSubscribe":   func(input *Input) error { return input.AsyncSubscribe(func(*Samples, *Infos) {}) },
+		"ChannelSubscribe": func(input *Input) error { return input.ChannelSubscribe(make(chan *Samples)) },
+		"Stream":           func(input *Input) error { _, err := input.Stream(context.Background()); return err },
+	}
+	for _, input := range []*Input{nil, {}} {
+		for name, call := range inputCalls {
+			assert.NotNil(t, call(input), name)
+		}
+		it := input.Iterate()
+		assert.False(t, it.Next())
+	}
+
+	samplesCalls := map[string]func(samples *Samples) (interface{}, interface{}){
+		"GetLength":      func(samples *Samples) (interface{}, interface{}) { return samples.GetLength(), 0 },
+		"GetUint8":       func(samples *Samples) (interface{}, interface{}) { return samples.GetUint8(0, "x"), uint8(0) },
+		"GetUint16":      func(samples *Samples) (interface{}, interface{}) { return samples.GetUint16(0, "x"), uint16(0) },
+		"GetUint32":      func(samples *Samples) (interface{}, interface{}) { return samples.GetUint32(0, "x"), uint32(0) },
+		"GetUint64":      func(samples *Samples) (interface{}, interface{}) { return samples.GetUint64(0, "x"), uint64(0) },
+		"GetInt8":        func(samples *Samples) (interface{}, interface{}) { return samples.GetInt8(0, "x"), int8(0) },
+		"GetInt16":       func(samples *Samples) (interface{}, interface{}) { return samples.GetInt16(0, "x"), int16(0) },
+		"GetInt32":       func(samples *Samples) (interface{}, interface{}) { return samples.GetInt32(0, "x"), int32(0) },
+		"GetInt64":       func(samples *Samples) (interface{}, interface{}) { return samples.GetInt64(0, "x"), int64(0) },
+		"GetFloat32":     func(samples *Samples) (interface{}, interface{}) { return samples.GetFloat32(0, "x"), float32(0) },
+		"GetFloat64":     func(samples *Samples) (interface{}, interface{}) { return samples.GetFloat64(0, "x"), float64(0) },
+		"GetInt":         func(samples *Samples) (interface{}, interface{}) { return samples.GetInt(0, "x"), 0 },
+		"GetUint":        func(samples *Samples) (interface{}, interface{}) { return samples.GetUint(0, "x"), uint(0) },
+		"GetByte":        func(samples *Samples) (interface{}, interface{}) { return samples.GetByte(0, "x"), byte(0) },
+		"GetRune":        func(samples *Samples) (interface{}, interface{}) { return samples.GetRune(0, "x"), rune(0) },
+		"GetBoolean":     func(samples *Samples) (interface{}, interface{}) { return samples.GetBoolean(0, "x"), false },
+		"GetString":      func(samples *Samples) (interface{}, interface{}) { return samples.GetString(0, "x"), "" },
+		"GetIntIndex":    func(samples *Samples) (interface{}, interface{}) { return samples.GetIntIndex(0, "x", 0), 0 },
+		"GetStringIndex": func(samples *Samples) (interface{}, interface{}) { return samples.GetStringIndex(0, "x", 0), "" },
+		"GetJSON": func(samples *Samples) (interface{}, interface{}) {
+			_, err := samples.GetJSON(0)
+			return err != nil, true
+		},
+		"GetSequenceLength": func(samples *Samples) (interface{}, interface{}) {
+			_, err := samples.GetSequenceLength(0, "x")
+			return err != nil, true
+		},
+		"GetMap": func(samples *Samples) (interface{}, interface{}) {
+			_, err := samples.GetMap(0, "x")
+			return err != nil, true
+		},
+		"GetInt32Array": func(samples *Samples) (interface{}, interface{}) {
+			_, err := samples.GetInt32Array(0, "x")
+			return err != nil, true
+		},
+		"GetBytes": func(samples *Samples) (interface{}, interface{}) {
+			_, err := samples.GetBytes(0, "x")
+			return err != nil, true
+		},
+		"GetKeyValue": func(samples *Samples) (interface{}, interface{}) {
+			_, err := samples.GetKeyValue(0)
+			return err != nil, true
+		},
+		"GetFieldType": func(samples *Samples) (interface{}, interface{}) {
+			_, err := samples.GetFieldType(0, "x")
+			return err != nil, true
+		},
+		"Get": func(samples *Samples) (interface{}, interface{}) {
+			var v interface{}
+			return samples.Get(0, &v) != nil, true
+		},
+		"ToMap": func(samples *Samples) (interface{}, interface{}) { _, err := samples.ToMap(0); return err != nil, true },
+	}
+	for _, samples := range []*Samples{nil, {}} {
+		for name, call := range samplesCalls {
+			actual, expected := call(samples)
+			assert.Equal(t, actual, expected, name)
+		}
+		for range samples.All() {
+			t.Error("All yields samples")
+		}
+	}
+
+	for _, infos := range []*Infos{nil, {}} {
+		assert.Equal(t, infos.GetLength(), 0)
+		assert.False(t, infos.IsValid(0))
+	}
+
+	// Entities of a deleted connector
+	connector := newTestConnector()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+	connector.Delete()
+	assert.NotNil(t, input.Take())
+	assert.Equal(t, input.Samples.GetLength(), 0)
+	assert.NotNil(t, output.Instance.SetString("st", "test"))
+	assert.NotNil(t, output.Write())
+
+	connectorCalls := map[string]func(connector *Connector) error{
+		"GetOutput": func(connector *Connector) error {
+			_, err := connector.GetOutput("MyPublisher::MyWriter")
+			return err
+		},
+		"GetInput": func(connector *Connector) error {
+			_, err := connector.GetInput("MySubscriber::MyReader")
+			return err
+		},
+		"Wait":            func(connector *Connector) error { return connector.Wait(0) },
+		"WaitWithContext": func(connector *Connector) error { return connector.WaitWithContext(context.Background()) },
+		"WaitAny":         func(connector *Connector) error { _, err := connector.WaitAny(0); return err },
+		"GetTopic": func(connector *Connector) error {
+			_, err := connector.GetTopic("MySubscriber::MyReader", "MyPublisher::MyWriter")
+			return err
+		},
+		"NewSafeOutput": func(connector *Connector) error {
+			_, err := connector.NewSafeOutput("MyPublisher::MyWriter")
+			return err
+		},
+		"NewSafeInput": func(connector *Connector) error {
+			_, err := connector.NewSafeInput("MySubscriber::MyReader")
+			return err
+		},
+	}
+	for _, connector := range []*Connector{nil, {}, connector} {
+		for name, call := range connectorCalls {
+			assert.NotNil(t, call(connector), name)
+		}
+	}
+}
+
+func TestInstanceClear(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetString("st", "test")
+	output.Instance.SetBoolean("b", true)
+	output.Instance.SetInt32("l", 5)
+	output.Instance.SetFloat64("d", 1.5)
+	err := output.Instance.Clear()
+	assert.Nil(t, err)
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Samples.GetString(0, "st"), "")
+	assert.Equal(t, input.Samples.GetBoolean(0, "b"), false)
+	assert.Equal(t, input.Samples.GetInt32(0, "l"), int32(0))
+	assert.Equal(t, input.Samples.GetFloat64(0, "d"), float64(0))
+
+	var nilInstance *Instance
+	assert.NotNil(t, nilInstance.Clear())
+}
+
+func TestWriteKeyed(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	// The members that are not set again are written with their previous value
+	output.Instance.SetString("st", "first")
+	output.Instance.SetInt32("l", 1)
+	output.Instance.SetFloat64("d", 2.5)
+	output.Write()
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	output.Instance.SetInt32("l", 2)
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Samples.GetString(0, "st"), "first")
+	assert.Equal(t, input.Samples.GetFloat64(0, "d"), 2.5)
+
+	err = output.WriteKeyed(map[string]interface{}{"st": "second"}, map[string]interface{}{"l": 3})
+	assert.Nil(t, err)
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Samples.GetString(0, "st"), "second")
+	assert.Equal(t, input.Samples.GetInt32(0, "l"), int32(3))
+	assert.Equal(t, input.Samples.GetFloat64(0, "d"), 2.5)
+
+	// After ClearMembers, the members that are not set have default values
+	output.ClearMembers()
+	err = output.WriteKeyed(map[string]interface{}{"st": "third"}, map[string]interface{}{"l": 4})
+	assert.Nil(t, err)
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Samples.GetString(0, "st"), "third")
+	assert.Equal(t, input.Samples.GetFloat64(0, "d"), float64(0))
+
+	err = output.WriteKeyed(map[string]interface{}{}, map[string]interface{}{"l": 4})
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "Missing key member: st")
+	err = output.WriteKeyed(map[string]interface{}{"st": "a", "l": 1}, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "Not a key member: l")
+	err = output.WriteKeyed(map[string]interface{}{"st": "a"}, map[string]interface{}{"st": "b"})
+	assert.NotNil(t, err)
+	var nilOutput *Output
+	err = nilOutput.WriteKeyed(map[string]interface{}{"st": "a"}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestWriteBatch(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	type batchSample struct {
+		St string `json:"st"`
+		L  int32  `json:"l"`
+	}
+	batch := []interface{}{}
+	for i := 0; i < 5; i++ {
+		batch = append(batch, batchSample{St: "batch", L: int32(i)})
+	}
+	err := output.WriteBatch(batch)
+	assert.Nil(t, err)
+
+	received := 0
+	for received < len(batch) {
+		err = connector.Wait(-1)
+		assert.Nil(t, err)
+		input.Take()
+		for i := 0; i < input.Samples.GetLength(); i++ {
+			assert.Equal(t, input.Samples.GetString(i, "st"), "batch")
+			assert.Equal(t, input.Samples.GetInt32(i, "l"), int32(received))
+			received++
+		}
+	}
+
+	err = output.WriteBatch([]interface{}{make(chan int)})
+	assert.NotNil(t, err)
+}
+
+func TestInstanceEncoder(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	encoder, err := output.NewEncoder()
+	assert.Nil(t, err)
+	for i := 1; i <= 3; i++ {
+		err = encoder.Encode(types.Test{St: strings.Repeat("encoder", i), L: int32(i)})
+		assert.Nil(t, err)
+	}
+
+	received := 0
+	for received < 3 {
+		err = connector.Wait(-1)
+		assert.Nil(t, err)
+		input.Take()
+		for i := 0; i < input.Samples.GetLength(); i++ {
+			received++
+			assert.Equal(t, input.Samples.GetString(i, "st"), strings.Repeat("encoder", received))
+			assert.Equal(t, input.Samples.GetInt32(i, "l"), int32(received))
+		}
+	}
+
+	err = encoder.Encode(make(chan int))
+	assert.NotNil(t, err)
+	assert.Nil(t, encoder.Close())
+	assert.NotNil(t, encoder.Encode(types.Test{}))
+	var nilOutput *Output
+	_, err = nilOutput.NewEncoder()
+	assert.NotNil(t, err)
+}
+
+func BenchmarkInstanceEncoder(b *testing.B) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	output := newTestOutput(connector)
+	sample := types.Test{St: "encoder", L: 1, D: 1.5}
+
+	b.Run("Set", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			output.Instance.Set(&sample)
+			output.Write()
+		}
+	})
+	b.Run("Encoder", func(b *testing.B) {
+		encoder, _ := output.NewEncoder()
+		defer encoder.Close()
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			encoder.Encode(&sample)
+		}
+	})
+}
+
+func BenchmarkWriteBatch(b *testing.B) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	output := newTestOutput(connector)
+
+	type batchSample struct {
+		St string  `json:"st"`
+		L  int32   `json:"l"`
+		D  float64 `json:"d"`
+	}
+	batch := make([]interface{}, 100)
+	for i := range batch {
+		batch[i] = batchSample{St: "batch", L: int32(i), D: float64(i)}
+	}
+
+	b.Run("Write", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for i := range batch {
+				output.Instance.SetString("st", "batch")
+				output.Instance.SetInt32("l", int32(i))
+				output.Instance.SetFloat64("d", float64(i))
+				output.Write()
+			}
+		}
+	})
+	b.Run("WriteBatch", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			output.WriteBatch(batch)
+		}
+	})
+}
+
+func TestGetUnreadCount(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+	input.Take()
+
+	count, err := input.GetUnreadCount()
+	assert.Nil(t, err)
+	assert.Equal(t, count, 0)
+
+	output.Instance.SetString("st", "unread")
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+
+	count, err = input.GetUnreadCount()
+	assert.Nil(t, err)
+	assert.Equal(t, count, 1)
+
+	// The samples are still in the queue
+	count, err = input.GetUnreadCount()
+	assert.Nil(t, err)
+	assert.Equal(t, count, 1)
+	count, err = input.TakeCount()
+	assert.Nil(t, err)
+	assert.Equal(t, count, 1)
+
+	var nilInput *Input
+	_, err = nilInput.GetUnreadCount()
+	assert.NotNil(t, err)
+}
+
+func TestEnumName(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestComplexInput(connector)
+	output := newTestComplexOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	err := output.Instance.SetEnumName("color", "GREEN")
+	assert.Nil(t, err)
+	err = output.Instance.SetEnumName("color", "YELLOW")
+	assert.NotNil(t, err)
+	err = output.Instance.SetEnumName("id", "GREEN")
+	assert.NotNil(t, err)
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	label, err := input.Samples.GetEnumName(0, "color")
+	assert.Nil(t, err)
+	assert.Equal(t, label, "GREEN")
+	assert.Equal(t, input.Samples.GetInt32(0, "color"), int32(5))
+
+	output.Instance.SetInt32("color", 6)
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	label, err = input.Samples.GetEnumName(0, "color")
+	assert.Nil(t, err)
+	assert.Equal(t, label, "BLUE")
+	_, err = input.Samples.GetEnumName(0, "invalid")
+	assert.NotNil(t, err)
+}
+
+func TestWaitAny(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+	complexInput := newTestComplexInput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+	complexInput.Take()
+
+	inputs, err := connector.WaitAny(100)
+	assert.Equal(t, err, ErrTimeout)
+	assert.Nil(t, inputs)
+
+	output.Instance.SetString("st", "any")
+	output.Write()
+	inputs, err = connector.WaitAny(-1)
+	assert.Nil(t, err)
+	assert.Equal(t, len(inputs), 1)
+	assert.True(t, inputs[0] == input)
+	assert.Equal(t, input.Samples.GetString(0, "st"), "any")
+
+	// The samples are still available to Take
+	count, err := input.TakeCount()
+	assert.Nil(t, err)
+	assert.Equal(t, count, 1)
+
+	var nilConnector *Connector
+	_, err = nilConnector.WaitAny(0)
+	assert.NotNil(t, err)
+}
+
+func TestDispatch(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan string, 1)
+	handlers := map[string]SampleHandler{
+		"MySubscriber::MyReader": func(samples *Samples, infos *Infos) {
+			received <- samples.GetString(0, "st")
+			cancel()
+		},
+	}
+
+	output.Instance.SetString("st", "dispatch")
+	output.Write()
+	err := connector.Dispatch(ctx, handlers)
+	assert.Equal(t, err, context.Canceled)
+	assert.Equal(t, <-received, "dispatch")
+
+	err = connector.Dispatch(context.Background(), map[string]SampleHandler{"invalid": nil})
+	assert.NotNil(t, err)
+
+	var nilConnector *Connector
+	err = nilConnector.Dispatch(context.Background(), handlers)
+	assert.NotNil(t, err)
+
+	// A deleted connector cannot dispatch
+	deleted := newTestConnector()
+	deleted.Delete()
+	err = deleted.Dispatch(context.Background(), handlers)
+	assert.NotNil(t, err)
+}
+
+func TestQoSOverrides(t *testing.T) {
+	_, curPath, _, _ := runtime.Caller(0)
+	xmlPath := path.Join(path.Dir(curPath), "./test/xml/Test.xml")
+	participantProfile := "MyParticipantLibrary::Zero"
+
+	connector, err := NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{
+		QoSOverrides: map[string]string{
+			"datareader_qos/history/kind":  "KEEP_LAST_HISTORY_QOS",
+			"datareader_qos/history/depth": "1",
+		},
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, connector)
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	// Only the last sample of the instance is kept
+	for i := 0; i < 3; i++ {
+		output.Instance.SetString("st", "qos")
+		output.Instance.SetInt32("l", int32(i))
+		output.Write()
+	}
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	time.Sleep(500 * time.Millisecond)
+	input.Take()
+	assert.Equal(t, input.Samples.GetLength(), 1)
+	assert.Equal(t, input.Samples.GetInt32(0, "l"), int32(2))
+
+	_, err = NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{
+		QoSOverrides: map[string]string{"participant_qos/transport_builtin/mask": "UDPV4"},
+	})
+	assert.NotNil(t, err)
+	_, err = NewConnectorWithOptions("MyParticipantLibrary::Invalid", xmlPath, ConnectorOptions{
+		QoSOverrides: map[string]string{"datawriter_qos/reliability/kind": "BEST_EFFORT_RELIABILITY_QOS"},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestPartitions(t *testing.T) {
+	_, curPath, _, _ := runtime.Caller(0)
+	xmlPath := path.Join(path.Dir(curPath), "./test/xml/Test.xml")
+	participantProfile := "MyParticipantLibrary::Zero"
+
+	connectorA, err := NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{
+		Partitions: map[string][]string{"MyPublisher": {"A"}, "MySubscriber": {"A"}},
+	})
+	assert.Nil(t, err)
+	defer connectorA.Delete()
+	connectorB, err := NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{
+		Partitions: map[string][]string{"MySubscriber": {"B"}},
+	})
+	assert.Nil(t, err)
+	defer connectorB.Delete()
+	inputA := newTestInput(connectorA)
+	inputB := newTestInput(connectorB)
+	output := newTestOutput(connectorA)
+	assert.Equal(t, output.Partitions(), []string{"A"})
+	assert.Equal(t, inputB.Partitions(), []string{"B"})
+	assert.Nil(t, newTestOutput(connectorB).Partitions())
+
+	// Take any pre-existing samples from cache
+	inputA.Take()
+	inputB.Take()
+
+	output.Instance.SetString("st", "partition")
+	output.Write()
+	err = connectorA.Wait(-1)
+	assert.Nil(t, err)
+	inputA.Take()
+	assert.Equal(t, inputA.Samples.GetString(0, "st"), "partition")
+
+	// The input on the other partition does not receive the sample
+	err = connectorB.Wait(1000)
+	assert.Equal(t, err, ErrTimeout)
+	assert.Equal(t, inputB.Take(), ErrNoData)
+
+	_, err = NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{
+		Partitions: map[string][]string{"InvalidPublisher": {"A"}},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestExpandEnv(t *testing.T) {
+	participantProfile := "MyParticipantLibrary::Zero"
+	xmlPath := path.Join(t.TempDir(), "Env.xml")
+	err := os.WriteFile(xmlPath, bytes.Replace(testXML, []byte(`domain_id="0"`), []byte(`domain_id="${TEST_DOMAIN_ID}"`), 1), 0644)
+	assert.Nil(t, err)
+	t.Setenv("TEST_DOMAIN_ID", "3")
+
+	connector, err := NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{ExpandEnv: true})
+	assert.Nil(t, err)
+	assert.NotNil(t, connector)
+	defer connector.Delete()
+	domainID, err := connector.GetDomainID()
+	assert.Nil(t, err)
+	assert.Equal(t, domainID, 3)
+	assert.Equal(t, connector.ConfigURL(), xmlPath)
+
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+	input.Take()
+	output.Instance.SetString("st", "env")
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Samples.GetString(0, "st"), "env")
+
+	// Without the option, the reference is passed as is
+	_, err = NewConnector(participantProfile, xmlPath)
+	assert.NotNil(t, err)
+	_, err = NewConnectorWithOptions(participantProfile, "invalid/path/to/xml", ConnectorOptions{ExpandEnv: true})
+	assert.NotNil(t, err)
+}
+
+func TestOptionalField(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestComplexInput(connector)
+	output := newTestComplexOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetInt32("id", 1)
+	output.Write()
+	output.Instance.SetInt32("opt", 3)
+	output.Write()
+	output.Instance.ClearField("opt")
+	output.Write()
+
+	received := 0
+	for received < 3 {
+		err := connector.Wait(-1)
+		assert.Nil(t, err)
+		input.Take()
+		for i := 0; i < input.Samples.GetLength(); i++ {
+			set, err := input.Samples.IsFieldSet(i, "opt")
+			assert.Nil(t, err)
+			assert.Equal(t, set, received == 1)
+			set, err = input.Samples.IsFieldSet(i, "id")
+			assert.Nil(t, err)
+			assert.Equal(t, set, true)
+			received++
+		}
+	}
+
+	_, err := input.Samples.IsFieldSet(0, "invalid")
+	assert.NotNil(t, err)
+	// Only a missing member means that it is not set
+	_, err = input.Samples.IsFieldSet(0, "int_seq[x]")
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "Invalid field name: int_seq[x]")
+}
+
+func TestClone(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetString("st", "clone")
+	output.Instance.SetInt64("ll", math.MaxInt64)
+	output.Write()
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	snapshot, err := input.Samples.Clone(0)
+	assert.Nil(t, err)
+	assert.True(t, snapshot.Valid)
+	_, err = input.Samples.Clone(input.Samples.GetLength())
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "Index out of range"))
+
+	// The snapshot stays valid after the next Take
+	input.Take()
+	var sample types.Test
+	err = snapshot.Get(&sample)
+	assert.Nil(t, err)
+	assert.Equal(t, sample.St, "clone")
+	sampleMap, err := snapshot.ToMap()
+	assert.Nil(t, err)
+	assert.Equal(t, sampleMap["ll"], json.Number("9223372036854775807"))
+
+	var nilSamples *Samples
+	_, err = nilSamples.Clone(0)
+	assert.NotNil(t, err)
+}
+
+func TestTakeN(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetString("st", "first")
+	output.Write()
+	output.Instance.SetString("st", "second")
+	output.Write()
+	output.Instance.SetString("st", "third")
+	output.Write()
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+	time.Sleep(500 * time.Millisecond)
+
+	count, err := input.TakeN(2)
+	assert.Nil(t, err)
+	assert.Equal(t, count, 3)
+	assert.Equal(t, input.Samples.GetLength(), 2)
+	assert.Equal(t, input.Infos.GetLength(), 2)
+	assert.Equal(t, input.Samples.GetString(1, "st"), "second")
+
+	_, err = input.TakeN(-1)
+	assert.NotNil(t, err)
+	_, err = input.TakeN(2)
+	assert.Equal(t, err, ErrNoData)
+}
+
+// countingCodec counts the calls to encoding/json
+type countingCodec struct {
+	marshal, unmarshal int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshal++
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshal++
+	return json.Unmarshal(data, v)
+}
+
+func TestSetCodec(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	codec := new(countingCodec)
+	SetCodec(codec)
+	defer SetCodec(nil)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	err := output.Instance.Set(&types.Test{St: "codec"})
+	assert.Nil(t, err)
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	var sample types.Test
+	err = input.Samples.Get(0, &sample)
+	assert.Nil(t, err)
+	assert.Equal(t, sample.St, "codec")
+	assert.Equal(t, codec.marshal, 1)
+	assert.Equal(t, codec.unmarshal, 1)
+}
+
+func TestGetJSONInto(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetString("st", "into")
+	output.Write()
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	jsonData, err := input.Samples.GetJSON(0)
+	assert.Nil(t, err)
+	buffer := []byte("prefix")
+	err = input.Samples.GetJSONInto(0, &buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, string(buffer), "prefix"+string(jsonData))
+
+	err = input.Samples.GetJSONInto(0, nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkGetJSON(b *testing.B) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetString("st", "benchmark")
+	output.Write()
+	connector.Wait(-1)
+	input.Take()
+
+	b.Run("GetJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			input.Samples.GetJSON(0)
+		}
+	})
+	b.Run("GetJSONInto", func(b *testing.B) {
+		b.ReportAllocs()
+		var buffer []byte
+		for n := 0; n < b.N; n++ {
+			buffer = buffer[:0]
+			input.Samples.GetJSONInto(0, &buffer)
+		}
+	})
+}
+
+func TestWatchDeadline(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	misses := make(chan struct{}, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := input.WatchDeadline(ctx, 200*time.Millisecond, func() { misses <- struct{}{} })
+	assert.Nil(t, err)
+
+	// No miss while samples keep arriving
+	for i := 0; i < 10; i++ {
+		output.Instance.SetString("st", "deadline")
+		output.Write()
+		err = connector.Wait(-1)
+		assert.Nil(t, err)
+		input.Take()
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.Equal(t, len(misses), 0)
+
+	// Then a miss every period
+	select {
+	case <-misses:
+	case <-time.After(time.Second):
+		t.Error("No deadline miss")
+	}
+
+	err = input.WatchDeadline(ctx, 0, func() {})
+	assert.NotNil(t, err)
+	err = input.WatchDeadline(ctx, time.Second, nil)
+	assert.NotNil(t, err)
+	var nilInput *Input
+	err = nilInput.WatchDeadline(ctx, time.Second, func() {})
+	assert.NotNil(t, err)
+}
+
+func TestTakeAllMaps(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetString("st", "map")
+	output.Instance.SetInt32("l", 3)
+	output.Write()
+	output.WriteWithParamsStruct(WriteParams{}.WithDispose())
+	time.Sleep(500 * time.Millisecond)
+
+	samples, infos, invalid, err := input.TakeAllMaps()
+	assert.Nil(t, err)
+	assert.Equal(t, len(samples), 1)
+	assert.Equal(t, len(infos), 1)
+	assert.Equal(t, invalid, 1)
+	assert.Equal(t, samples[0]["st"], "map")
+	assert.Equal(t, samples[0]["l"], json.Number("3"))
+	assert.Equal(t, infos[0].Valid, true)
+
+	// The same meta data is returned by GetInfo
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	info, err := input.Infos.GetInfo(0)
+	assert.Nil(t, err)
+	assert.Equal(t, info, SampleInfo{Valid: true})
+	_, err = input.Infos.GetInfo(input.Infos.GetLength())
+	assert.NotNil(t, err)
+	var nilInfos *Infos
+	_, err = nilInfos.GetInfo(0)
+	assert.NotNil(t, err)
+
+	_, _, _, err = input.TakeAllMaps()
+	assert.Equal(t, err, ErrNoData)
+	var nilInput *Input
+	_, _, _, err = nilInput.TakeAllMaps()
+	assert.NotNil(t, err)
+}
+
+func TestRecorder(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	var buffer bytes.Buffer
+	recorder := NewRecorder(&buffer)
+	err := input.RecordTo(recorder)
+	assert.Nil(t, err)
+
+	before := time.Now().UnixNano()
+	output.Instance.Set(map[string]interface{}{"st": "first", "l": 1})
+	output.Write()
+	output.Instance.Set(map[string]interface{}{"st": "second", "l": 2})
+	output.Write()
+	output.WriteWithParamsStruct(WriteParams{}.WithDispose())
+	time.Sleep(500 * time.Millisecond)
+	err = input.Take()
+	assert.Nil(t, err)
+	assert.Nil(t, recorder.Err())
+
+	// One line per valid sample
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Equal(t, len(lines), 2)
+	var record Record
+	err = json.Unmarshal([]byte(lines[1]), &record)
+	assert.Nil(t, err)
+	assert.Equal(t, record.Input, "MySubscriber::MyReader")
+	assert.Equal(t, string(record.Key), `{"st":"second"}`)
+	assert.True(t, record.TakenAt >= before)
+	var data map[string]interface{}
+	err = json.Unmarshal(record.Data, &data)
+	assert.Nil(t, err)
+	assert.Equal(t, data["st"], "second")
+	assert.Equal(t, data["l"], float64(2))
+
+	// Nothing is recorded once stopped
+	err = input.RecordTo(nil)
+	assert.Nil(t, err)
+	recording := buffer.String()
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, buffer.String(), recording)
+
+	// Replaying the recording writes the same samples
+	count, err := Replay(strings.NewReader(recording), output)
+	assert.Nil(t, err)
+	assert.Equal(t, count, 2)
+	time.Sleep(500 * time.Millisecond)
+	err = input.Take()
+	assert.Nil(t, err)
+	assert.Equal(t, input.Samples.GetLength(), 2)
+	assert.Equal(t, input.Samples.GetString(0, "st"), "first")
+	assert.Equal(t, input.Samples.GetInt32(1, "l"), int32(2))
+
+	_, err = Replay(strings.NewReader("{\"input\":\"x\"}\n"), output)
+	assert.NotNil(t, err)
+	_, err = Replay(strings.NewReader("not json"), output)
+	assert.NotNil(t, err)
+	var nilInput *Input
+	assert.NotNil(t, nilInput.RecordTo(recorder))
+	_, err = Replay(strings.NewReader(recording), nil)
+	assert.NotNil(t, err)
+}
+
+func TestPlayer(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	recording := `{"input":"MySubscriber::MyReader","taken_at":1000000000,"data":{"st":"first","l":1}}
+{"input":"MySubscriber::MyReader","taken_at":1400000000,"data":{"st":"second","l":2}}
+`
+	player, err := NewPlayer(strings.NewReader(recording), output)
+	assert.Nil(t, err)
+	assert.Equal(t, player.Len(), 2)
+
+	// The 400ms between the samples are replayed in 200ms
+	player.Speed = 2
+	start := time.Now()
+	err = player.Play(context.Background())
+	assert.Nil(t, err)
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= 200*time.Millisecond)
+	assert.True(t, elapsed < 400*time.Millisecond)
+	time.Sleep(500 * time.Millisecond)
+	err = input.Take()
+	assert.Nil(t, err)
+	assert.Equal(t, input.Samples.GetLength(), 2)
+	assert.Equal(t, input.Samples.GetString(0, "st"), "first")
+	assert.Equal(t, input.Samples.GetString(1, "st"), "second")
+
+	// A loop is replayed until the context is done
+	player.Loop = true
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	err = player.Play(ctx)
+	assert.Equal(t, err, context.DeadlineExceeded)
+	time.Sleep(500 * time.Millisecond)
+	err = input.Take()
+	assert.Nil(t, err)
+	assert.True(t, input.Samples.GetLength() > 2)
+
+	player.Speed = -1
+	assert.NotNil(t, player.Play(context.Background()))
+	var nilPlayer *Player
+	assert.NotNil(t, nilPlayer.Play(context.Background()))
+	assert.Equal(t, nilPlayer.Len(), 0)
+
+	// A nil context never gets done
+	player.Speed = 0
+	player.Loop = false
+	err = player.Play(nil)
+	assert.Nil(t, err)
+
+	// A recording without duration cannot be looped
+	instant := `{"input":"MySubscriber::MyReader","taken_at":1000000000,"data":{"st":"first","l":1}}
+{"input":"MySubscriber::MyReader","taken_at":1000000000,"data":{"st":"second","l":2}}
+`
+	player, err = NewPlayer(strings.NewReader(instant), output)
+	assert.Nil(t, err)
+	player.Loop = true
+	assert.NotNil(t, player.Play(context.Background()))
+	player, err = NewPlayer(strings.NewReader(""), output)
+	assert.Nil(t, err)
+	player.Loop = true
+	assert.NotNil(t, player.Play(context.Background()))
+	_, err = NewPlayer(strings.NewReader("{\"input\":\"x\"}\n"), output)
+	assert.NotNil(t, err)
+	_, err = NewPlayer(strings.NewReader(recording), nil)
+	assert.NotNil(t, err)
+}
+
+func TestDefaultWaitTimeout(t *testing.T) {
+	_, curPath, _, _ := runtime.Caller(0)
+	xmlPath := path.Join(path.Dir(curPath), "./test/xml/Test.xml")
+	participantProfile := "MyParticipantLibrary::Zero"
+
+	connector, err := NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{DefaultWaitTimeout: 100 * time.Millisecond})
+	assert.Nil(t, err)
+	defer connector.Delete()
+	input := newTestInput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	start := time.Now()
+	err = connector.Wait(DefaultTimeout)
+	assert.Equal(t, err, ErrTimeout)
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	err = input.TakeTimeout(DefaultTimeout)
+	assert.Equal(t, err, ErrTimeout)
+
+	err = connector.SetDefaultWaitTimeout(300 * time.Millisecond)
+	assert.Nil(t, err)
+	start = time.Now()
+	_, err = connector.WaitAny(DefaultTimeout)
+	assert.Equal(t, err, ErrTimeout)
+	assert.True(t, time.Since(start) >= 300*time.Millisecond)
+
+	// Explicit timeouts are unchanged
+	start = time.Now()
+	err = connector.Wait(0)
+	assert.Equal(t, err, ErrTimeout)
+	assert.True(t, time.Since(start) < 300*time.Millisecond)
+
+	assert.Equal(t, connector.resolveTimeout(5), 5)
+	assert.Equal(t, connector.resolveTimeout(-1), -1)
+	connector.SetDefaultWaitTimeout(0)
+	assert.Equal(t, connector.resolveTimeout(DefaultTimeout), -1)
+	connector.SetDefaultWaitTimeout(time.Microsecond)
+	assert.Equal(t, connector.resolveTimeout(DefaultTimeout), 1)
+
+	var nullConnector *Connector
+	assert.NotNil(t, nullConnector.SetDefaultWaitTimeout(time.Second))
+}
+
+func TestTakeTimeout(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	start := time.Now()
+	err := input.TakeTimeout(200)
+	assert.Equal(t, err, ErrTimeout)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		output.Instance.SetString("st", "timeout")
+		output.Write()
+	}()
+	err = input.TakeTimeout(-1)
+	assert.Nil(t, err)
+	assert.Equal(t, input.Samples.GetString(0, "st"), "timeout")
+
+	var nilInput *Input
+	err = nilInput.TakeTimeout(0)
+	assert.NotNil(t, err)
+}
+
+func TestTakeByInstance(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestComplexInput(connector)
+	output := newTestComplexOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	for _, id := range []int32{1, 2, 1} {
+		output.Instance.SetInt32("id", id)
+		output.Write()
+	}
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+	time.Sleep(500 * time.Millisecond)
+
+	instances, err := input.TakeByInstance()
+	assert.Nil(t, err)
+	assert.Equal(t, instances, map[string][]int{`{"id":1}`: {0, 2}, `{"id":2}`: {1}})
+
+	_, err = input.TakeByInstance()
+	assert.Equal(t, err, ErrNoData)
+}
+
+func TestNewConnectorWithRetry(t *testing.T) {
+	_, curPath, _, _ := runtime.Caller(0)
+	xmlPath := path.Join(path.Dir(curPath), "./test/xml/Test.xml")
+	participantProfile := "MyParticipantLibrary::Zero"
+
+	connector, err := NewConnectorWithRetry(participantProfile, xmlPath, 3, 10*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, connector)
+	connector.Delete()
+
+	// 10ms + 20ms of backoff between the 3 attempts
+	start := time.Now()
+	connector, err = NewConnectorWithRetry(participantProfile, "invalid/path/to/xml", 3, 10*time.Millisecond)
+	assert.NotNil(t, err)
+	assert.Nil(t, connector)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	connector, err = NewConnectorWithRetryContext(ctx, participantProfile, "invalid/path/to/xml", 100, time.Second)
+	assert.Equal(t, err, context.DeadlineExceeded)
+	assert.Nil(t, connector)
+}
+
+func TestGetStringInto(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetString("st", "into")
+	output.Write()
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+
+	buffer := []byte("string ")
+	err = input.Samples.GetStringInto(0, "st", &buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, string(buffer), "string into")
+
+	err = input.Samples.GetStringInto(0, "st", nil)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkGetString(b *testing.B) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetString("st", "benchmark")
+	output.Write()
+	connector.Wait(-1)
+	input.Take()
+
+	b.Run("GetString", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			input.Samples.GetString(0, "st")
+		}
+	})
+	b.Run("GetStringInto", func(b *testing.B) {
+		b.ReportAllocs()
+		var buffer []byte
+		for n := 0; n < b.N; n++ {
+			buffer = buffer[:0]
+			input.Samples.GetStringInto(0, "st", &buffer)
+		}
+	})
+}
+
+func TestTopic(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+
+	topic, err := connector.GetTopic("MySubscriber::MyReader", "MyPublisher::MyWriter")
+	assert.Nil(t, err)
+	assert.NotNil(t, topic)
+
+	// Take any pre-existing samples from cache
+	topic.Take()
+
+	// The reader receives the samples of the writer, so the request is the reply
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var reply types.Test
+	err = topic.WriteAndWaitReply(ctx, &types.Test{St: "request"}, &reply)
+	assert.Nil(t, err)
+	assert.Equal(t, reply.St, "request")
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = topic.WriteAndWaitReply(ctx, make(chan int), &reply)
+	assert.NotNil(t, err)
+
+	_, err = connector.GetTopic("invalid", "MyPublisher::MyWriter")
+	assert.NotNil(t, err)
+	_, err = connector.GetTopic("MySubscriber::MyReader", "invalid")
+	assert.NotNil(t, err)
+}
+
+func TestStats(t *testing.T) {
+	_, curPath, _, _ := runtime.Caller(0)
+	xmlPath := path.Join(path.Dir(curPath), "./test/xml/Test.xml")
+	participantProfile := "MyParticipantLibrary::Zero"
+
+	connector, err := NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{EnableStats: true})
+	assert.Nil(t, err)
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+	connector.ResetStats()
+
+	output.Instance.SetString("st", "stats")
+	output.Write()
+	err = output.WriteBatch([]interface{}{types.Test{St: "stats"}})
+	assert.Nil(t, err)
+	err = output.WriteBatch([]interface{}{make(chan int)})
+	assert.NotNil(t, err)
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	time.Sleep(500 * time.Millisecond)
+	input.Read()
+	input.Take()
+
+	stats := connector.Stats()
+	assert.Equal(t, stats.SamplesWritten, uint64(2))
+	assert.Equal(t, stats.SamplesRead, uint64(2))
+	assert.Equal(t, stats.SamplesTaken, uint64(2))
+	assert.Equal(t, stats.TakenByInput, map[string]uint64{"MySubscriber::MyReader": 2})
+	assert.Equal(t, stats.WritesFailed, uint64(1))
+	assert.Equal(t, stats.TakesFailed, uint64(0))
+	assert.NotNil(t, stats.LastError)
+
+	connector.ResetStats()
+	assert.Equal(t, connector.Stats(), Stats{})
+
+	// Disabled by default
+	connector2 := newTestConnector()
+	defer connector2.Delete()
+	output2 := newTestOutput(connector2)
+	output2.Write()
+	assert.Equal(t, connector2.Stats(), Stats{})
+}
+
+func TestNextContext(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var sample types.Test
+	err := input.NextContext(ctx, &sample)
+	assert.Equal(t, err, context.DeadlineExceeded)
+
+	output.Instance.SetString("st", "first")
+	output.Write()
+	output.Instance.SetString("st", "second")
+	output.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	time.Sleep(500 * time.Millisecond)
+
+	// Both samples are taken at once, and the second one is buffered
+	err = input.NextContext(context.Background(), &sample)
+	assert.Nil(t, err)
+	assert.Equal(t, sample.St, "first")
+	err = input.NextContext(context.Background(), &sample)
+	assert.Nil(t, err)
+	assert.Equal(t, sample.St, "second")
+
+	// Only an invalid sample
+	output.WriteWithParamsStruct(WriteParams{}.WithDispose())
+	err = input.NextContext(context.Background(), &sample)
+	assert.Equal(t, err, ErrNoData)
+
+	// Data that is not taken on another input does not make it spin
+	complexInput := newTestComplexInput(connector)
+	complexOutput := newTestComplexOutput(connector)
+	complexOutput.Instance.SetInt32("id", 1)
+	complexOutput.Write()
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	var buffer bytes.Buffer
+	SetTrace(&buffer)
+	ctx, cancel = context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	err = input.NextContext(ctx, &sample)
+	SetTrace(nil)
+	assert.Equal(t, err, context.DeadlineExceeded)
+	assert.True(t, strings.Count(buffer.String(), "RTIDDSConnector_wait(") < 100)
+	complexInput.Take()
+
+	var nilInput *Input
+	err = nilInput.NextContext(context.Background(), &sample)
+	assert.NotNil(t, err)
+}
+
+func TestWriteJSONWithIdentity(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	first, err := output.WriteJSONWithIdentity(`{"st":"identity","l":1}`)
+	assert.Nil(t, err)
+	assert.Equal(t, first.SequenceNumber, 1)
+	err = connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Samples.GetString(0, "st"), "identity")
+
+	second, err := output.WriteJSONWithIdentity(`{"st":"identity","l":2}`)
+	assert.Nil(t, err)
+	assert.Equal(t, second.SequenceNumber, 2)
+	assert.Equal(t, second.WriterGUID, first.WriterGUID)
+
+	other, err := connector.GetOutput("MyPublisher::MyWriter")
+	assert.Nil(t, err)
+	third, err := other.WriteJSONWithIdentity(`{"st":"identity"}`)
+	assert.Nil(t, err)
+	assert.NotEqual(t, third.WriterGUID, first.WriterGUID)
+
+	var nilOutput *Output
+	_, err = nilOutput.WriteJSONWithIdentity(`{}`)
+	assert.NotNil(t, err)
+}
+
+func TestWriteManyWithParams(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	var guid [16]byte
+	guid[0] = 1
+	requests := []WriteRequest{}
+	for i := 1; i <= 3; i++ {
+		identity := Identity{WriterGUID: guid, SequenceNumber: i}
+		requests = append(requests, WriteRequest{
+			Value:  types.Test{St: "request", L: int32(i)},
+			Params: WriteParams{}.WithIdentity(identity),
+		})
+	}
+	err := output.WriteManyWithParams(requests)
+	assert.Nil(t, err)
+
+	received := 0
+	for received < len(requests) {
+		err = connector.Wait(-1)
+		assert.Nil(t, err)
+		input.Take()
+		for i := 0; i < input.Samples.GetLength(); i++ {
+			received++
+			assert.Equal(t, input.Samples.GetInt32(i, "l"), int32(received))
+		}
+	}
+
+	err = output.WriteManyWithParams([]WriteRequest{{Value: make(chan int)}})
+	assert.NotNil(t, err)
+	var nilOutput *Output
+	err = nilOutput.WriteManyWithParams(requests)
+	assert.NotNil(t, err)
+}
+
+func TestValidateJSON(t *testing.T) {
+	_, curPath, _, _ := runtime.Caller(0)
+	xmlPath := path.Join(path.Dir(curPath), "./test/xml/Test.xml")
+	participantProfile := "MyParticipantLibrary::Zero"
+
+	connector, err := NewConnectorWithOptions(participantProfile, xmlPath, ConnectorOptions{ValidateJSON: true})
+	assert.Nil(t, err)
+	defer connector.Delete()
+	output := newTestComplexOutput(connector)
+
+	err = output.Instance.SetJSON([]byte(`{"id":1,"int_seq":[1,2],"color":"BLUE","opt":null,"payload":"AQI="}`))
+	assert.Nil(t, err)
+	err = output.Instance.SetInt64("int_seq[1]", 3)
+	assert.Nil(t, err)
+	err = output.Instance.SetUint64("int_seq[1]", 3)
+	assert.Nil(t, err)
+
+	// Elements cannot be set alone through JSON
+	err = output.Instance.SetInt64("int_seq[1]", 1<<60)
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "Element paths are not supported for int_seq[1]: set the whole array or sequence")
+	err = output.Instance.ClearField("int_seq[1]")
+	assert.NotNil(t, err)
+	err = output.Instance.SetInt32Array("int_seq[1]", []int32{1})
+	assert.NotNil(t, err)
+
+	err = output.Instance.SetJSON([]byte(`{"id":1,"unknown":2}`))
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "Unknown field unknown")
+	err = output.Instance.SetJSON([]byte(`{"id":"1"}`))
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "Type mismatch for id: expected an integer of type int32")
+	err = output.Instance.SetJSON([]byte(`{"int_seq":[1,2.5]}`))
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "Type mismatch for int_seq[2]: expected an integer of type int32")
+	err = output.Instance.SetJSON([]byte(`{"int_array":1}`))
+	assert.NotNil(t, err)
+	err = output.Instance.SetJSON([]byte(`{"color":"YELLOW"}`))
+	assert.NotNil(t, err)
+	err = output.Instance.SetJSON([]byte(`[]`))
+	assert.NotNil(t, err)
+
+	// WriteBatch checks every sample before writing any
+	err = output.WriteBatch([]interface{}{map[string]int{"id": 1}, map[string]int{"unknown": 2}})
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "Unknown field unknown")
+
+	// Without the option, only ValidateJSON checks the sample
+	testOutput := newTestOutput(newTestConnector())
+	defer testOutput.connector.Delete()
+	err = testOutput.Instance.SetJSON([]byte(`{"st":"test","b":true,"d":1.5}`))
+	assert.Nil(t, err)
+	err = testOutput.Instance.ValidateJSON([]byte(`{"b":1}`))
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "Type mismatch for b: expected a boolean")
+	err = testOutput.Instance.ValidateJSON([]byte(`{"us":-1}`))
+	assert.NotNil(t, err)
+}
+
+func TestSampleIndexRange(t *testing.T) {
+	connector := newTestConnector()
+	defer connector.Delete()
+	input := newTestInput(connector)
+	output := newTestOutput(connector)
+
+	// Take any pre-existing samples from cache
+	input.Take()
+
+	output.Instance.SetString("st", "test")
+	output.Instance.SetInt32("l", 7)
+	output.Write()
+	err := connector.Wait(-1)
+	assert.Nil(t, err)
+	input.Take()
+	assert.Equal(t, input.Samples.GetLength(), 1)
+
+	// Index 0 is the first sample
+	assert.Equal(t, input.Samples.GetString(0, "st"), "test")
+	assert.Equal(t, input.Samples.GetInt32(0, "l"), int32(7))
+	assert.Equal(t, input.Infos.IsValid(0), true)
+	_, err = input.Samples.GetJSON(0)
+	assert.Nil(t, err)
+
+	// Out-of-range indexes
+	for _, index := range []int{-1, 1, 100} {
+		_, err = input.Samples.GetJSON(index)
+		assert.NotNil(t, err)
+		_, err = input.Samples.GetSequenceLength(index, "l")
+		assert.NotNil(t, err)
+		assert.Equal(t, input.Samples.GetString(index, "st"), "")
+		assert.Equal(t, input.Samples.GetUint8(index, "c"), uint8(0))
+		assert.Equal(t, input.Infos.IsValid(index), false)
+	}
+	_, err = input.Samples.GetJSON(1)
+	assert.Equal(t, err.Error(), "Index out of range: 1 (1 samples from index 0)")
+}
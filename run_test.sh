@@ -1,6 +1,6 @@
 #!/bin/bash
 
-go get -v github.com/stretchr/testify
+go mod download
 
 if [[ "$OSTYPE" == "linux-gnu" ]]; then
 	export LD_LIBRARY_PATH=$PWD/rticonnextdds-connector/lib/x64Linux2.6gcc4.4.5:$LD_LIBRARY_PATH
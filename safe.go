@@ -0,0 +1,151 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"errors"
+	"sync"
+)
+
+// SafeOutput is an output that can be shared between goroutines.
+// All its methods are serialized with a mutex. The underlying output is not
+// exposed: setting members and writing should be done with Do or WriteSample
+// so that another goroutine cannot modify the instance in between.
+type SafeOutput struct {
+	output *Output
+	mu     sync.Mutex
+}
+
+// SafeInput is an input that can be shared between goroutines.
+// All its methods are serialized with a mutex. The underlying input is not
+// exposed: accessing Samples and Infos after a Read or Take should be done
+// with Do so that another goroutine cannot replace the samples in between.
+type SafeInput struct {
+	input *Input
+	mu    sync.Mutex
+}
+
+// NewSafeOutput returns an output object that is safe for concurrent use
+func (connector *Connector) NewSafeOutput(outputName string) (output *SafeOutput, err error) {
+	unsafeOutput, err := connector.GetOutput(outputName)
+	if err != nil {
+		return nil, err
+	}
+	return &SafeOutput{output: unsafeOutput}, nil
+}
+
+// NewSafeInput returns an input object that is safe for concurrent use
+func (connector *Connector) NewSafeInput(inputName string) (input *SafeInput, err error) {
+	unsafeInput, err := connector.GetInput(inputName)
+	if err != nil {
+		return nil, err
+	}
+	return &SafeInput{input: unsafeInput}, nil
+}
+
+// Name is a function to retrieve the name of the underlying output
+func (output *SafeOutput) Name() string {
+	if output == nil {
+		return ""
+	}
+	return output.output.Name()
+}
+
+// Name is a function to retrieve the name of the underlying input
+func (input *SafeInput) Name() string {
+	if input == nil {
+		return ""
+	}
+	return input.input.Name()
+}
+
+// Write is a function to write a DDS data instance in an output
+func (output *SafeOutput) Write() error {
+	if output == nil || output.output == nil {
+		return errors.New("Output is null")
+	}
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	return output.output.Write()
+}
+
+// WriteWithParams is a function to write a DDS data instance in an output
+// with the given parameters (see Output.WriteWithParams)
+func (output *SafeOutput) WriteWithParams(jsonParams string) error {
+	if output == nil || output.output == nil {
+		return errors.New("Output is null")
+	}
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	return output.output.WriteWithParams(jsonParams)
+}
+
+// ClearMembers is a function to initialize a DDS data instance in an output
+func (output *SafeOutput) ClearMembers() error {
+	if output == nil || output.output == nil {
+		return errors.New("Output is null")
+	}
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	return output.output.ClearMembers()
+}
+
+// WriteSample is a function to set all the members of the instance
+// from v and write it, as a single operation
+func (output *SafeOutput) WriteSample(v interface{}) error {
+	return output.Do(func(instance *Instance) error {
+		err := instance.Set(v)
+		if err != nil {
+			return err
+		}
+		return instance.output.Write()
+	})
+}
+
+// Do is a function to run f while holding the lock of the output
+func (output *SafeOutput) Do(f func(instance *Instance) error) error {
+	if output == nil || output.output == nil {
+		return errors.New("Output is null")
+	}
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	return f(output.output.Instance)
+}
+
+// Read is a function to read DDS samples from the DDS DataReader
+func (input *SafeInput) Read() error {
+	if input == nil || input.input == nil {
+		return errors.New("Input is null")
+	}
+	input.mu.Lock()
+	defer input.mu.Unlock()
+	return input.input.Read()
+}
+
+// Take is a function to take DDS samples from the DDS DataReader
+func (input *SafeInput) Take() error {
+	if input == nil || input.input == nil {
+		return errors.New("Input is null")
+	}
+	input.mu.Lock()
+	defer input.mu.Unlock()
+	return input.input.Take()
+}
+
+// Do is a function to run f while holding the lock of the input
+func (input *SafeInput) Do(f func(samples *Samples, infos *Infos) error) error {
+	if input == nil || input.input == nil {
+		return errors.New("Input is null")
+	}
+	input.mu.Lock()
+	defer input.mu.Unlock()
+	return f(input.input.Samples, input.input.Infos)
+}
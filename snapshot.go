@@ -0,0 +1,60 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// SampleSnapshot is a copy of a sample made by Samples.Clone.
+// Unlike Samples and Infos, it stays valid after following Take or Read calls
+// and can be passed to another goroutine. The C layer does not expose the
+// identity, timestamps or states of a sample, so only its validity is kept.
+type SampleSnapshot struct {
+	Valid bool   // false when the sample carries no data (e.g. a dispose)
+	JSON  []byte // the sample data in JSON, nil for an invalid sample
+}
+
+// Clone is a function to copy a sample and its validity into a snapshot
+func (samples *Samples) Clone(index int) (snapshot *SampleSnapshot, err error) {
+	_, err = samples.nativeIndex(index)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot = new(SampleSnapshot)
+	snapshot.Valid = samples.input.Infos.IsValid(index)
+	if snapshot.Valid {
+		snapshot.JSON, err = samples.GetJSON(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return snapshot, nil
+}
+
+// Get is a function to unmarshal the sample data of the snapshot into v
+func (snapshot *SampleSnapshot) Get(v interface{}) (err error) {
+	return getCodec().Unmarshal(snapshot.JSON, v)
+}
+
+// ToMap is a function to retrieve the sample data of the snapshot as a map.
+// See Samples.ToMap.
+func (snapshot *SampleSnapshot) ToMap() (sample map[string]interface{}, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(snapshot.JSON))
+	decoder.UseNumber()
+	err = decoder.Decode(&sample)
+	if err != nil {
+		return nil, err
+	}
+	return sample, nil
+}
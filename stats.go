@@ -0,0 +1,105 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"sync"
+)
+
+// Stats are the counters of a connector created with the EnableStats option
+type Stats struct {
+	SamplesWritten uint64 // samples written by Write, WriteWithParams and WriteBatch
+	SamplesTaken   uint64 // samples taken by Take
+	SamplesRead    uint64 // samples read by Read
+	WritesFailed   uint64 // calls to the write functions that returned an error
+	TakesFailed    uint64 // calls to Take or Read that returned an error other than ErrNoData
+	LastError      error  // last error counted in WritesFailed or TakesFailed
+
+	TakenByInput map[string]uint64 // SamplesTaken by name of the input, nil until a sample is taken
+}
+
+// connectorStats holds the Stats of a connector
+type connectorStats struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Stats is a function to get a snapshot of the counters of the connector.
+// The counters are zero when the connector was not created with the
+// EnableStats option.
+func (connector *Connector) Stats() Stats {
+	if connector == nil || connector.stats == nil {
+		return Stats{}
+	}
+	connector.stats.mu.Lock()
+	defer connector.stats.mu.Unlock()
+	stats := connector.stats.stats
+	if stats.TakenByInput != nil {
+		stats.TakenByInput = make(map[string]uint64, len(connector.stats.stats.TakenByInput))
+		for name, count := range connector.stats.stats.TakenByInput {
+			stats.TakenByInput[name] = count
+		}
+	}
+	return stats
+}
+
+// ResetStats is a function to reset the counters of the connector to zero
+func (connector *Connector) ResetStats() {
+	if connector == nil || connector.stats == nil {
+		return
+	}
+	connector.stats.mu.Lock()
+	defer connector.stats.mu.Unlock()
+	connector.stats.stats = Stats{}
+}
+
+// recordWrite counts the samples written by an output, or its failure
+func (output *Output) recordWrite(count int, err error) {
+	if output == nil || output.connector == nil || output.connector.stats == nil {
+		return
+	}
+	stats := output.connector.stats
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	if err != nil {
+		stats.stats.WritesFailed++
+		stats.stats.LastError = err
+		return
+	}
+	stats.stats.SamplesWritten += uint64(count)
+}
+
+// recordTake counts the samples taken, or read if take is false, by an input,
+// or its failure
+func (input *Input) recordTake(take bool, count int, err error) {
+	if input == nil || input.connector == nil || input.connector.stats == nil {
+		return
+	}
+	stats := input.connector.stats
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	if err != nil && err != ErrNoData {
+		stats.stats.TakesFailed++
+		stats.stats.LastError = err
+		return
+	}
+	if take {
+		stats.stats.SamplesTaken += uint64(count)
+		if count > 0 {
+			if stats.stats.TakenByInput == nil {
+				stats.stats.TakenByInput = map[string]uint64{}
+			}
+			stats.stats.TakenByInput[input.name] += uint64(count)
+		}
+	} else {
+		stats.stats.SamplesRead += uint64(count)
+	}
+}
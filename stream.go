@@ -0,0 +1,94 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"context"
+)
+
+// streamBufferSize bounds the number of events buffered for a slow consumer.
+// When the buffer is full, the streaming goroutine stops taking samples and
+// the rest stay in the DataReader queue, as limited by its QoS.
+const streamBufferSize = 64
+
+// SampleEvent is a snapshot of a sample delivered by Input.Stream.
+// It stays valid after following Take or Read calls.
+type SampleEvent struct {
+	Valid bool   // false when the sample carries no data (e.g. a dispose)
+	JSON  []byte // the sample data in JSON, nil for an invalid sample
+	Err   error  // the error that stopped the stream, only set on the last event
+}
+
+// Stream is a function to receive DDS samples through a Go channel.
+// Internally, it waits for data, takes DDS samples from the DDS DataReader
+// and sends a SampleEvent for each of them to the returned channel.
+// The channel is closed when the context is done or the connector is deleted,
+// so it can be consumed with a for range loop. When waiting for data or taking
+// fails, an event with Err set is sent before the channel is closed.
+// As for TakeTimeout, data available on another input of the connector makes
+// the stream pause between waits, up to a few milliseconds, until it is taken.
+func (input *Input) Stream(ctx context.Context) (<-chan SampleEvent, error) {
+	err := input.check()
+	if err != nil {
+		return nil, err
+	}
+	connector := input.connector
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan SampleEvent, streamBufferSize)
+
+	connector.streams.Add(1)
+	go func() {
+		defer connector.streams.Done()
+		defer close(events)
+		defer cancel()
+
+		go func() {
+			select {
+			case <-connector.done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		for {
+			err := input.takeWithContext(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				select {
+				case events <- SampleEvent{Err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+
+			first := input.Samples.firstIndex()
+			for i := first; i < first+input.Samples.GetLength(); i++ {
+				event := SampleEvent{Valid: input.Infos.IsValid(i)}
+				if event.Valid {
+					event.JSON, _ = input.Samples.GetJSON(i)
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
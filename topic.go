@@ -0,0 +1,79 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"context"
+	"errors"
+)
+
+// Topic bundles an input and an output, e.g. to send requests and receive
+// replies, or to publish and subscribe on the same topic
+type Topic struct {
+	*Input
+	*Output
+}
+
+// GetTopic is a function to get the input named readerName and the output
+// named writerName of a connector as a Topic
+func (connector *Connector) GetTopic(readerName string, writerName string) (topic *Topic, err error) {
+	err = connector.check()
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := connector.GetInput(readerName)
+	if err != nil {
+		return nil, err
+	}
+	output, err := connector.GetOutput(writerName)
+	if err != nil {
+		return nil, err
+	}
+	return &Topic{Input: input, Output: output}, nil
+}
+
+// WriteAndWaitReply is a function to write request with the output, then wait
+// for a sample on the input and unmarshal it into reply. It returns the error
+// of the context when the context is done first.
+//
+// The C layer does not expose the identity of the samples, so the reply is
+// not correlated with the request: the first valid sample taken is the reply,
+// and the other samples taken with it are discarded. Send one request at a time,
+// or carry a request id in the data type to match the replies.
+func (topic *Topic) WriteAndWaitReply(ctx context.Context, request interface{}, reply interface{}) (err error) {
+	if topic == nil {
+		err = errors.New("Topic is null")
+		return err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	err = topic.Output.WriteBatch([]interface{}{request})
+	if err != nil {
+		return err
+	}
+
+	for {
+		err = topic.Input.takeWithContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		first := topic.Input.Samples.firstIndex()
+		for i := first; i < first+topic.Input.Samples.GetLength(); i++ {
+			if topic.Input.Infos.IsValid(i) {
+				return topic.Input.Samples.Get(i, reply)
+			}
+		}
+	}
+}
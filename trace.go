@@ -0,0 +1,60 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	traceEnabled atomic.Bool // checked before building a trace, so that tracing costs nothing when disabled
+	traceMutex   sync.Mutex
+	traceWriter  io.Writer
+)
+
+// SetTrace is a function to write a line to w for each call to the main
+// functions of the C layer: creation and deletion of the connector, lookup of
+// the inputs and outputs, write, clear, set of JSON samples, read, take and
+// wait, with their arguments and the return code of wait. The accessors of
+// single members are not traced. Pass nil to disable tracing.
+// It is a debugging aid: the lines are not meant to be parsed.
+func SetTrace(w io.Writer) {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	traceWriter = w
+	traceEnabled.Store(w != nil)
+}
+
+// traceCall writes the trace of a call to the C layer. result is empty for
+// the functions that do not return anything.
+func traceCall(function string, args string, result string) {
+	traceMutex.Lock()
+	defer traceMutex.Unlock()
+	if traceWriter == nil {
+		return
+	}
+	if result != "" {
+		result = " = " + result
+	}
+	fmt.Fprintf(traceWriter, "%s %s(%s)%s\n", time.Now().Format(time.RFC3339Nano), function, args, result)
+}
+
+// nativeResult returns the trace of the pointer returned by a function of the C layer
+func nativeResult(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "null"
+}
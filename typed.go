@@ -0,0 +1,158 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"errors"
+)
+
+// SampleInfo is the meta data of a sample returned by TypedInput and
+// Input.TakeAllMaps. The C layer does not expose the identity, timestamps or
+// states of a sample, so only its validity is available.
+type SampleInfo struct {
+	Valid bool // false when the sample carries no data (e.g. a dispose)
+}
+
+// GetInfo is a function to retrieve the meta data of a sample in a SampleInfo.
+// Unlike IsValid, it returns an error for an index out of the range of the samples.
+func (infos *Infos) GetInfo(index int) (info SampleInfo, err error) {
+	err = infos.check()
+	if err != nil {
+		return info, err
+	}
+	_, err = infos.input.Samples.nativeIndex(index)
+	if err != nil {
+		return info, err
+	}
+	info.Valid = infos.IsValid(index)
+	return info, nil
+}
+
+// TypedOutput is an output that writes values of type T.
+// T is a struct with json tags matching the members of the DDS type.
+type TypedOutput[T any] struct {
+	*Output
+}
+
+// TypedInput is an input that reads values of type T.
+// T is a struct with json tags matching the members of the DDS type.
+type TypedInput[T any] struct {
+	*Input
+}
+
+// GetTypedOutput returns an output object writing values of type T
+func GetTypedOutput[T any](connector *Connector, outputName string) (output *TypedOutput[T], err error) {
+	untypedOutput, err := connector.GetOutput(outputName)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedOutput[T]{Output: untypedOutput}, nil
+}
+
+// GetTypedInput returns an input object reading values of type T
+func GetTypedInput[T any](connector *Connector, inputName string) (input *TypedInput[T], err error) {
+	untypedInput, err := connector.GetInput(inputName)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedInput[T]{Input: untypedInput}, nil
+}
+
+// Write is a function to set all the members of the instance from v and write it
+func (output *TypedOutput[T]) Write(v T) (err error) {
+	if output == nil || output.Output == nil {
+		err = errors.New("Output is null")
+		return err
+	}
+
+	err = output.Instance.Set(&v)
+	if err != nil {
+		return err
+	}
+	return output.Output.Write()
+}
+
+// Take is a function to take DDS samples from the DDS DataReader and return
+// them with their meta data. The value of an invalid sample is the zero value.
+func (input *TypedInput[T]) Take() (values []T, infos []SampleInfo, err error) {
+	if input == nil || input.Input == nil {
+		err = errors.New("Input is null")
+		return nil, nil, err
+	}
+
+	err = input.Input.Take()
+	if err != nil {
+		return nil, nil, err
+	}
+	return input.decode()
+}
+
+// Read is a function to read DDS samples from the DDS DataReader and return
+// them with their meta data. The value of an invalid sample is the zero value.
+func (input *TypedInput[T]) Read() (values []T, infos []SampleInfo, err error) {
+	if input == nil || input.Input == nil {
+		err = errors.New("Input is null")
+		return nil, nil, err
+	}
+
+	err = input.Input.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	return input.decode()
+}
+
+func (input *TypedInput[T]) decode() (values []T, infos []SampleInfo, err error) {
+	length := input.Samples.GetLength()
+	values = make([]T, length)
+	infos = make([]SampleInfo, length)
+	first := input.Samples.firstIndex()
+	for i := 0; i < length; i++ {
+		infos[i].Valid = input.Infos.IsValid(first + i)
+		if !infos[i].Valid {
+			continue
+		}
+		err = input.Samples.Get(first+i, &values[i])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return values, infos, nil
+}
+
+// TakeAllMaps is a function to take DDS samples from the DDS DataReader and
+// return the valid ones as maps (see Samples.ToMap), with their meta data in
+// a parallel slice. The invalid samples (e.g. disposes) are skipped and only
+// counted in invalid. It returns ErrNoData when there are no samples to take.
+func (input *Input) TakeAllMaps() (samples []map[string]interface{}, infos []SampleInfo, invalid int, err error) {
+	err = input.Take()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	length := input.Samples.GetLength()
+	samples = make([]map[string]interface{}, 0, length)
+	infos = make([]SampleInfo, 0, length)
+	first := input.Samples.firstIndex()
+	for i := first; i < first+length; i++ {
+		if !input.Infos.IsValid(i) {
+			invalid++
+			continue
+		}
+		sample, err := input.Samples.ToMap(i)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		samples = append(samples, sample)
+		infos = append(infos, SampleInfo{Valid: true})
+	}
+	return samples, infos, invalid, nil
+}
@@ -2,7 +2,7 @@ package types
 
 // Test is the struct for testing
 type Test struct {
-	St  string  `json:"st"`
+	St  string  `json:"st" dds:"key"`
 	B   bool    `json:"b"`
 	C   uint8   `json:"c"`
 	S   int16   `json:"s"`
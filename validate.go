@@ -0,0 +1,186 @@
+/*****************************************************************************
+*   (c) 2005-2015 Copyright, Real-Time Innovations.  All rights reserved.    *
+*                                                                            *
+* No duplications, whole or partial, manual or electronic, may be made       *
+* without express written permission.  Any such copies, or revisions thereof,*
+* must display this notice unaltered.                                        *
+* This code contains trade secrets of Real-Time Innovations, Inc.            *
+*                                                                            *
+*****************************************************************************/
+
+package rti
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// integerBits gives the size of the integer types of the XML configuration,
+// negative for the signed ones
+var integerBits = map[string]int{
+	"int8": -8, "int16": -16, "int32": -32, "int64": -64,
+	"short": -16, "long": -32, "longLong": -64,
+	"uint8": 8, "uint16": 16, "uint32": 32, "uint64": 64,
+	"byte": 8, "octet": 8, "unsignedShort": 16, "unsignedLong": 32, "unsignedLongLong": 64,
+}
+
+// floatTypes are the floating-point types of the XML configuration
+var floatTypes = map[string]bool{
+	"float32": true, "float64": true, "float128": true,
+	"float": true, "double": true, "longDouble": true,
+}
+
+// ValidateJSON is a function to check a JSON sample against the type of the
+// output in the XML configuration, without setting it. It returns an error
+// naming the first unknown member or the first member with a value of the
+// wrong type. Members of types it does not know (e.g. unions) are not checked.
+// See also the ValidateJSON option of ConnectorOptions.
+func (instance *Instance) ValidateJSON(jsonData []byte) (err error) {
+	err = instance.check()
+	if err != nil {
+		return err
+	}
+
+	configs, err := instance.output.connector.config()
+	if err != nil {
+		return err
+	}
+	typ, err := instance.output.connector.entityType(instance.output.name)
+	if err != nil {
+		return err
+	}
+	return validateStruct(configs, typ, json.RawMessage(jsonData), "")
+}
+
+// validateStruct checks the JSON of a struct. prefix is the name of the
+// struct member followed by a dot, empty for the sample itself.
+func validateStruct(configs []*xmlDDS, typ *xmlStruct, value json.RawMessage, prefix string) (err error) {
+	var fields map[string]json.RawMessage
+	if !bytes.HasPrefix(bytes.TrimSpace(value), []byte("{")) || json.Unmarshal(value, &fields) != nil {
+		err = errors.New("Type mismatch for " + structName(prefix) + ": expected an object")
+		return err
+	}
+
+	members, err := allMembers(configs, typ)
+	if err != nil {
+		return err
+	}
+	for name, field := range fields {
+		// Members set by field name may address an element, e.g. "seq[1]"
+		memberName, indexed := name, false
+		if i := strings.IndexByte(name, '['); i > 0 {
+			memberName, indexed = name[:i], true
+		}
+
+		var member *xmlMember
+		for i := range members {
+			if members[i].Name == memberName {
+				member = &members[i]
+			}
+		}
+		if member == nil {
+			err = errors.New("Unknown field " + prefix + name)
+			return err
+		}
+		if indexed {
+			err = validateElement(configs, member, field, prefix+name)
+		} else {
+			err = validateMember(configs, member, field, prefix+name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structName returns the name of a struct for the errors of validateStruct
+func structName(prefix string) string {
+	if prefix == "" {
+		return "the sample"
+	}
+	return prefix[:len(prefix)-1]
+}
+
+// validateMember checks the JSON of a member, an array or a sequence
+// of elements if the member is declared so
+func validateMember(configs []*xmlDDS, member *xmlMember, value json.RawMessage, fieldName string) (err error) {
+	if string(value) == "null" && member.Optional {
+		return nil
+	}
+	if member.ArrayDimensions == "" && member.SequenceMaxLength == "" {
+		return validateElement(configs, member, value, fieldName)
+	}
+
+	// Octet sequences may be represented as base64 strings
+	if integerBits[member.Type] == 8 && bytes.HasPrefix(value, []byte("\"")) {
+		return nil
+	}
+	var elements []json.RawMessage
+	if !bytes.HasPrefix(value, []byte("[")) || json.Unmarshal(value, &elements) != nil {
+		err = errors.New("Type mismatch for " + fieldName + ": expected an array")
+		return err
+	}
+	for i, element := range elements {
+		err = validateElement(configs, member, element, elementName(fieldName, i))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateElement checks the JSON of a single value of the type of a member
+func validateElement(configs []*xmlDDS, member *xmlMember, value json.RawMessage, fieldName string) (err error) {
+	mismatch := func(expected string) error {
+		return errors.New("Type mismatch for " + fieldName + ": expected " + expected)
+	}
+
+	switch {
+	case member.Type == "string" || member.Type == "wstring":
+		var text string
+		if json.Unmarshal(value, &text) != nil {
+			return mismatch("a string")
+		}
+		return member.checkString(fieldName, text)
+	case member.Type == "boolean":
+		if string(value) != "true" && string(value) != "false" {
+			return mismatch("a boolean")
+		}
+	case integerBits[member.Type] != 0:
+		bits := integerBits[member.Type]
+		if bits < 0 {
+			_, err = strconv.ParseInt(string(value), 10, -bits)
+		} else {
+			_, err = strconv.ParseUint(string(value), 10, bits)
+		}
+		if err != nil {
+			return mismatch("an integer of type " + member.Type)
+		}
+	case floatTypes[member.Type]:
+		_, err = strconv.ParseFloat(string(value), 64)
+		if err != nil {
+			return mismatch("a number")
+		}
+	case member.Type == "nonBasic":
+		typ, err := findStruct(configs, member.NonBasicTypeName)
+		if err == nil {
+			return validateStruct(configs, typ, value, fieldName+".")
+		}
+		enum, err := findEnum(configs, member.NonBasicTypeName)
+		if err == nil {
+			var label string
+			if json.Unmarshal(value, &label) == nil {
+				if _, ok := enum.values()[label]; !ok {
+					return mismatch("a label of enum " + enum.Name)
+				}
+			} else if _, err = strconv.Atoi(string(value)); err != nil {
+				return mismatch("a value of enum " + enum.Name)
+			}
+		}
+	}
+	return nil
+}